@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/importer"
+)
+
+// runImport imports the chats of an archive file into the database of the service and prints the result
+// of every chat. It returns the exit code: 1 if chats failed to import, 2 if nothing could be imported.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: main import [-format node|chatgpt] [-user email] <archive.json>")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "format of the archive, node or chatgpt, detected from its structure if empty")
+	userEmail := flags.String("user", "", "user the chats are imported for, required for ChatGPT archives")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	switch importer.Format(*format) {
+	case "", importer.NodeFormat, importer.ChatGPTFormat:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown archive format %q, use node or chatgpt\n", *format)
+		return 2
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read archive: %v\n", err)
+		return 2
+	}
+	archive, err := importer.Parse(data, importer.Format(*format))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read archive: %v\n", err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 2
+	}
+	dbConn, err := sql.Open("postgres", cfg.Database.DatabaseUrl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		return 2
+	}
+	defer dbConn.Close()
+
+	// Chats are imported one by one, an interrupted import is completed by running it again
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := dbConn.PingContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 2
+	}
+	results := importer.NewImporter(dbConn, database.New(dbConn)).Import(ctx, archive, *userEmail)
+
+	counts := make(map[importer.Status]int)
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "STATUS\tEXTERNAL ID\tCHAT\tMESSAGES\tTITLE / ERROR")
+	for _, result := range results {
+		counts[result.Status]++
+		chatID, detail := "-", result.Title
+		if result.Status != importer.StatusFailed {
+			chatID = result.ChatID.String()
+		} else {
+			detail = result.Error
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%d\t%s\n", result.Status, result.ExternalID, chatID, result.Messages, detail)
+	}
+	out.Flush()
+	fmt.Printf("%s archive: %d imported, %d skipped, %d failed\n", archive.Format,
+		counts[importer.StatusImported], counts[importer.StatusSkipped], counts[importer.StatusFailed])
+
+	if counts[importer.StatusFailed] > 0 {
+		return 1
+	}
+	return 0
+}
@@ -5,19 +5,76 @@ package api
 
 import (
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// Defines values for DataErasureDTOInitiatedBy.
+const (
+	Admin DataErasureDTOInitiatedBy = "admin"
+	Self  DataErasureDTOInitiatedBy = "self"
+)
+
+// Defines values for DocumentFormat.
+const (
+	HTML     DocumentFormat = "HTML"
+	MARKDOWN DocumentFormat = "MARKDOWN"
+	PDF      DocumentFormat = "PDF"
+	TEXT     DocumentFormat = "TEXT"
 )
 
 // Defines values for ErrorMessageCode.
 const (
-	FORBIDDEN        ErrorMessageCode = "FORBIDDEN"
-	RESOURCENOTFOUND ErrorMessageCode = "RESOURCE_NOT_FOUND"
-	SERVERERROR      ErrorMessageCode = "SERVER_ERROR"
-	UNAUTHORIZED     ErrorMessageCode = "UNAUTHORIZED"
-	VALIDATIONERROR  ErrorMessageCode = "VALIDATION_ERROR"
+	CONFLICT            ErrorMessageCode = "CONFLICT"
+	FORBIDDEN           ErrorMessageCode = "FORBIDDEN"
+	PAYLOADTOOLARGE     ErrorMessageCode = "PAYLOAD_TOO_LARGE"
+	QUOTAEXCEEDED       ErrorMessageCode = "QUOTA_EXCEEDED"
+	RATELIMITED         ErrorMessageCode = "RATE_LIMITED"
+	RESOURCENOTFOUND    ErrorMessageCode = "RESOURCE_NOT_FOUND"
+	SERVERERROR         ErrorMessageCode = "SERVER_ERROR"
+	SERVICEUNAVAILABLE  ErrorMessageCode = "SERVICE_UNAVAILABLE"
+	UNAUTHORIZED        ErrorMessageCode = "UNAUTHORIZED"
+	UNPROCESSABLEENTITY ErrorMessageCode = "UNPROCESSABLE_ENTITY"
+	UPSTREAMERROR       ErrorMessageCode = "UPSTREAM_ERROR"
+	UPSTREAMTIMEOUT     ErrorMessageCode = "UPSTREAM_TIMEOUT"
+	VALIDATIONERROR     ErrorMessageCode = "VALIDATION_ERROR"
+)
+
+// Defines values for ImportReportDTOFormat.
+const (
+	ImportReportDTOFormatChatgpt ImportReportDTOFormat = "chatgpt"
+	ImportReportDTOFormatNode    ImportReportDTOFormat = "node"
+)
+
+// Defines values for ImportStatus.
+const (
+	ImportStatusFAILED   ImportStatus = "FAILED"
+	ImportStatusIMPORTED ImportStatus = "IMPORTED"
+	ImportStatusSKIPPED  ImportStatus = "SKIPPED"
+)
+
+// Defines values for IngestionJobDTOKind.
+const (
+	REINDEX IngestionJobDTOKind = "REINDEX"
+	UPLOAD  IngestionJobDTOKind = "UPLOAD"
+)
+
+// Defines values for IngestionStatus.
+const (
+	IngestionStatusCHUNKING  IngestionStatus = "CHUNKING"
+	IngestionStatusDONE      IngestionStatus = "DONE"
+	IngestionStatusEMBEDDING IngestionStatus = "EMBEDDING"
+	IngestionStatusFAILED    IngestionStatus = "FAILED"
+	IngestionStatusQUEUED    IngestionStatus = "QUEUED"
+)
+
+// Defines values for JsonRpcMessageJsonrpc.
+const (
+	N20 JsonRpcMessageJsonrpc = "2.0"
 )
 
 // Defines values for SenderType.
@@ -27,18 +84,160 @@ const (
 	USER    SenderType = "USER"
 )
 
+// Defines values for ImportChatsParamsFormat.
+const (
+	ImportChatsParamsFormatChatgpt ImportChatsParamsFormat = "chatgpt"
+	ImportChatsParamsFormatNode    ImportChatsParamsFormat = "node"
+)
+
+// Defines values for GetChatsParamsSort.
+const (
+	CreatedAt      GetChatsParamsSort = "createdAt"
+	LastActiveDate GetChatsParamsSort = "lastActiveDate"
+)
+
+// Defines values for ExportChatParamsFormat.
+const (
+	Html     ExportChatParamsFormat = "html"
+	Json     ExportChatParamsFormat = "json"
+	Markdown ExportChatParamsFormat = "markdown"
+)
+
+// BranchDTO defines model for BranchDTO.
+type BranchDTO struct {
+	// Active True for the branch getMessages returns
+	Active bool `json:"active"`
+
+	// ForkMessageId First message of the branch that is not part of the active branch, missing for the active branch
+	ForkMessageId *openapi_types.UUID `json:"forkMessageId,omitempty"`
+
+	// LastActiveDate Date of the last message
+	LastActiveDate LocalDateTime `json:"lastActiveDate"`
+
+	// LeafId Last message of the branch, pass it to switchBranch to continue the branch
+	LeafId openapi_types.UUID `json:"leafId"`
+
+	// MessageCount Number of messages in the branch
+	MessageCount int32 `json:"messageCount"`
+
+	// Preview Beginning of the last message
+	Preview *string `json:"preview,omitempty"`
+}
+
+// ChatArchive JSON archive of chats: the export of the Node.js variant, {"chats": [...]} or a plain array of chats with id, title, userEmail, createdAt, lastActiveDate and messages (id, senderType or role, content, createdAt), or the conversations.json of a ChatGPT export
+type ChatArchive = interface{}
+
 // ChatDTO defines model for ChatDTO.
 type ChatDTO struct {
+	// Archived True if the chat is archived
+	Archived *bool `json:"archived,omitempty"`
+
+	// CreatedAt Date when the chat was created
+	CreatedAt *LocalDateTime `json:"createdAt,omitempty"`
+
 	// Id Unique identifier for the chat (auto-generated)
-	Id *int `json:"id,omitempty"`
+	Id *openapi_types.UUID `json:"id,omitempty"`
 
 	// LastActiveDate Date when the chat was last active
 	LastActiveDate *LocalDateTime `json:"lastActiveDate,omitempty"`
 
-	// Title Name of the chat (derived from first message)
+	// LastMessage Beginning of the latest user message or reply of the active conversation, only set in chat lists
+	LastMessage *string `json:"lastMessage,omitempty"`
+
+	// MessageCount Number of user messages and replies in the active conversation of the chat, only set in chat lists
+	MessageCount *int32 `json:"messageCount,omitempty"`
+
+	// Pinned True if the chat is pinned to the top of the chat list
+	Pinned *bool `json:"pinned,omitempty"`
+
+	// Title Name of the chat, derived from the first message until a title is generated or set by the user
 	Title *string `json:"title,omitempty"`
 }
 
+// ChatExportDTO defines model for ChatExportDTO.
+type ChatExportDTO struct {
+	Chat ChatDTO `json:"chat"`
+
+	// ExportedAt Date of the export
+	ExportedAt LocalDateTime `json:"exportedAt"`
+
+	// Messages Active conversation of the chat, oldest message first
+	Messages []ExportedMessageDTO `json:"messages"`
+}
+
+// ChatPageDTO defines model for ChatPageDTO.
+type ChatPageDTO struct {
+	Chats *[]ChatDTO `json:"chats,omitempty"`
+
+	// NextCursor Cursor of the next page, null on the last page
+	NextCursor *string `json:"nextCursor"`
+}
+
+// ChatUsageDTO defines model for ChatUsageDTO.
+type ChatUsageDTO struct {
+	ChatId           *openapi_types.UUID `json:"chatId,omitempty"`
+	CompletionTokens *int64              `json:"completionTokens,omitempty"`
+	PromptTokens     *int64              `json:"promptTokens,omitempty"`
+	Title            *string             `json:"title,omitempty"`
+	TotalTokens      *int64              `json:"totalTokens,omitempty"`
+}
+
+// DailyUsageDTO defines model for DailyUsageDTO.
+type DailyUsageDTO struct {
+	CompletionTokens *int64 `json:"completionTokens,omitempty"`
+
+	// Date Day of the usage (UTC)
+	Date         *openapi_types.Date `json:"date,omitempty"`
+	PromptTokens *int64              `json:"promptTokens,omitempty"`
+	TotalTokens  *int64              `json:"totalTokens,omitempty"`
+}
+
+// DataErasureDTO defines model for DataErasureDTO.
+type DataErasureDTO struct {
+	// Chats Number of deleted chats
+	Chats    int32              `json:"chats"`
+	ErasedAt LocalDateTime      `json:"erasedAt"`
+	Id       openapi_types.UUID `json:"id"`
+
+	// InitiatedBy Whether the user or an admin requested the erasure
+	InitiatedBy DataErasureDTOInitiatedBy `json:"initiatedBy"`
+
+	// Messages Number of deleted messages
+	Messages int32 `json:"messages"`
+
+	// SubjectHash HMAC-SHA256 of the lower-cased email address keyed with a server-side secret, so the erasure record keeps no personal data.
+	// Empty if the service has no secret configured.
+	SubjectHash string `json:"subjectHash"`
+
+	// TokenUsage Number of anonymized token usage records
+	TokenUsage int32 `json:"tokenUsage"`
+}
+
+// DataErasureDTOInitiatedBy Whether the user or an admin requested the erasure
+type DataErasureDTOInitiatedBy string
+
+// DocumentDTO defines model for DocumentDTO.
+type DocumentDTO struct {
+	// Chunks Number of chunks the document is split into
+	Chunks    *int32              `json:"chunks,omitempty"`
+	CreatedAt *LocalDateTime      `json:"createdAt,omitempty"`
+	Format    *DocumentFormat     `json:"format,omitempty"`
+	Id        *openapi_types.UUID `json:"id,omitempty"`
+
+	// Source File name of the document, uploading a document with the same file name replaces it
+	Source *string `json:"source,omitempty"`
+
+	// Status Progress of an ingestion, QUEUED -> CHUNKING -> EMBEDDING -> DONE or FAILED
+	Status *IngestionStatus `json:"status,omitempty"`
+
+	// Title Title of the document, taken from the document or the file name
+	Title     *string        `json:"title,omitempty"`
+	UpdatedAt *LocalDateTime `json:"updatedAt,omitempty"`
+}
+
+// DocumentFormat defines model for DocumentFormat.
+type DocumentFormat string
+
 // ErrorMessage defines model for ErrorMessage.
 type ErrorMessage struct {
 	// Code Error code that identifies the error type
@@ -60,13 +259,138 @@ type ErrorMessage struct {
 // ErrorMessageCode Error code that identifies the error type
 type ErrorMessageCode string
 
+// ExportedMessageDTO defines model for ExportedMessageDTO.
+type ExportedMessageDTO struct {
+	// ChatId Reference to the chat this message belongs to (auto-generated)
+	ChatId *openapi_types.UUID `json:"chatId,omitempty"`
+
+	// Content Content of the message
+	Content *string `json:"content,omitempty"`
+
+	// CreatedAt Date when the message was created (auto-generated)
+	CreatedAt *LocalDateTime `json:"createdAt,omitempty"`
+
+	// Id Unique identifier for the message (auto-generated)
+	Id *openapi_types.UUID `json:"id,omitempty"`
+
+	// Incomplete True if the generation of this reply was interrupted and the content is partial
+	Incomplete *bool `json:"incomplete,omitempty"`
+
+	// ParentId Message this message follows in the conversation, missing for the first message
+	ParentId *openapi_types.UUID `json:"parentId,omitempty"`
+
+	// Prompt System prompt template version a BACKEND message was rendered from, or that was in effect for an LLM reply
+	Prompt *PromptVersionDTO `json:"prompt,omitempty"`
+
+	// SenderType Type of sender (automatically set to 'user' for user messages)
+	SenderType *SenderType `json:"senderType,omitempty"`
+
+	// Sources Knowledge base excerpts an LLM reply is based on, cited in the content by their number, e.g. [1]
+	Sources *[]SourceDTO `json:"sources,omitempty"`
+
+	// Usage Model that generated an LLM reply and the tokens it consumed
+	Usage *MessageUsageDTO `json:"usage,omitempty"`
+
+	// Variant Number of this variant of a user message or LLM reply, starting at 1, editing the message or regenerating the reply adds variants
+	Variant *int32 `json:"variant,omitempty"`
+
+	// VariantCount Number of variants of a user message or LLM reply, so variantCount - 1 alternatives exist
+	VariantCount *int32 `json:"variantCount,omitempty"`
+}
+
+// ImportReportDTO defines model for ImportReportDTO.
+type ImportReportDTO struct {
+	// Chats Result of every chat in the order of the archive
+	Chats  []ImportResultDTO `json:"chats"`
+	Failed int32             `json:"failed"`
+
+	// Format Format of the archive
+	Format   ImportReportDTOFormat `json:"format"`
+	Imported int32                 `json:"imported"`
+	Skipped  int32                 `json:"skipped"`
+}
+
+// ImportReportDTOFormat Format of the archive
+type ImportReportDTOFormat string
+
+// ImportResultDTO defines model for ImportResultDTO.
+type ImportResultDTO struct {
+	// ChatId The imported chat, for skipped chats the chat imported before
+	ChatId *openapi_types.UUID `json:"chatId,omitempty"`
+
+	// Error Why the chat failed to import
+	Error *string `json:"error,omitempty"`
+
+	// ExternalId Id of the chat in the archive
+	ExternalId *string `json:"externalId,omitempty"`
+
+	// MessageCount Number of messages of the chat
+	MessageCount *int32 `json:"messageCount,omitempty"`
+
+	// Status IMPORTED, SKIPPED if the chat was imported before or FAILED if it is invalid or could not be stored
+	Status ImportStatus `json:"status"`
+	Title  *string      `json:"title,omitempty"`
+
+	// UserEmail User the chat was imported for
+	UserEmail *string `json:"userEmail,omitempty"`
+}
+
+// ImportStatus IMPORTED, SKIPPED if the chat was imported before or FAILED if it is invalid or could not be stored
+type ImportStatus string
+
+// IngestionJobDTO defines model for IngestionJobDTO.
+type IngestionJobDTO struct {
+	// Chunks Number of chunks of the document
+	Chunks    *int32         `json:"chunks,omitempty"`
+	CreatedAt *LocalDateTime `json:"createdAt,omitempty"`
+
+	// DocumentId Document of the job, missing until a new document has been stored
+	DocumentId *openapi_types.UUID `json:"documentId,omitempty"`
+
+	// EmbeddedChunks Number of chunks embedded so far
+	EmbeddedChunks *int32 `json:"embeddedChunks,omitempty"`
+
+	// Error Reason of the failure if the status is FAILED
+	Error *string              `json:"error,omitempty"`
+	Id    *openapi_types.UUID  `json:"id,omitempty"`
+	Kind  *IngestionJobDTOKind `json:"kind,omitempty"`
+
+	// Source File name of the document
+	Source *string `json:"source,omitempty"`
+
+	// Status Progress of an ingestion, QUEUED -> CHUNKING -> EMBEDDING -> DONE or FAILED
+	Status    *IngestionStatus `json:"status,omitempty"`
+	UpdatedAt *LocalDateTime   `json:"updatedAt,omitempty"`
+}
+
+// IngestionJobDTOKind defines model for IngestionJobDTO.Kind.
+type IngestionJobDTOKind string
+
+// IngestionStatus Progress of an ingestion, QUEUED -> CHUNKING -> EMBEDDING -> DONE or FAILED
+type IngestionStatus string
+
+// JsonRpcMessage JSON-RPC 2.0 request, notification or response
+type JsonRpcMessage struct {
+	Error *map[string]interface{} `json:"error,omitempty"`
+
+	// Id Request id, a string or number, missing for notifications and null for parse errors
+	Id      *interface{}            `json:"id"`
+	Jsonrpc JsonRpcMessageJsonrpc   `json:"jsonrpc"`
+	Method  *string                 `json:"method,omitempty"`
+	Params  *map[string]interface{} `json:"params,omitempty"`
+	Result  *map[string]interface{} `json:"result,omitempty"`
+}
+
+// JsonRpcMessageJsonrpc defines model for JsonRpcMessage.Jsonrpc.
+type JsonRpcMessageJsonrpc string
+
 // LocalDateTime defines model for LocalDateTime.
 type LocalDateTime = time.Time
 
 // MessageDTO defines model for MessageDTO.
 type MessageDTO struct {
 	// ChatId Reference to the chat this message belongs to (auto-generated)
-	ChatId *int `json:"chatId,omitempty"`
+	ChatId *openapi_types.UUID `json:"chatId,omitempty"`
 
 	// Content Content of the message
 	Content *string `json:"content,omitempty"`
@@ -75,47 +399,473 @@ type MessageDTO struct {
 	CreatedAt *LocalDateTime `json:"createdAt,omitempty"`
 
 	// Id Unique identifier for the message (auto-generated)
-	Id *int `json:"id,omitempty"`
+	Id *openapi_types.UUID `json:"id,omitempty"`
+
+	// Incomplete True if the generation of this reply was interrupted and the content is partial
+	Incomplete *bool `json:"incomplete,omitempty"`
+
+	// ParentId Message this message follows in the conversation, missing for the first message
+	ParentId *openapi_types.UUID `json:"parentId,omitempty"`
+
+	// Prompt System prompt template version a BACKEND message was rendered from, or that was in effect for an LLM reply
+	Prompt *PromptVersionDTO `json:"prompt,omitempty"`
+
+	// SenderType Type of sender (automatically set to 'user' for user messages)
+	SenderType *SenderType `json:"senderType,omitempty"`
+
+	// Sources Knowledge base excerpts an LLM reply is based on, cited in the content by their number, e.g. [1]
+	Sources *[]SourceDTO `json:"sources,omitempty"`
+
+	// Variant Number of this variant of a user message or LLM reply, starting at 1, editing the message or regenerating the reply adds variants
+	Variant *int32 `json:"variant,omitempty"`
+
+	// VariantCount Number of variants of a user message or LLM reply, so variantCount - 1 alternatives exist
+	VariantCount *int32 `json:"variantCount,omitempty"`
+}
+
+// MessageUsageDTO Model that generated an LLM reply and the tokens it consumed
+type MessageUsageDTO struct {
+	CompletionTokens *int32  `json:"completionTokens,omitempty"`
+	Model            *string `json:"model,omitempty"`
+	PromptTokens     *int32  `json:"promptTokens,omitempty"`
+}
+
+// ProblemDetails RFC 7807 problem details, returned instead of ErrorMessage if the client accepts application/problem+json
+type ProblemDetails struct {
+	// Code Error code that identifies the error type, same as in ErrorMessage
+	Code string `json:"code"`
+
+	// Detail Human-readable explanation specific to this occurrence of the problem
+	Detail *string `json:"detail,omitempty"`
+
+	// Details List of field-value pairs with additional error information
+	Details *[]struct {
+		// Field The name of the field with an error
+		Field *string `json:"field,omitempty"`
+
+		// Value The error message or problematic value for this field
+		Value *string `json:"value,omitempty"`
+	} `json:"details,omitempty"`
+
+	// Instance Request path the problem occurred on
+	Instance *string `json:"instance,omitempty"`
+
+	// Status HTTP status code
+	Status int `json:"status"`
+
+	// Title Short, human-readable summary of the problem type
+	Title string `json:"title"`
+
+	// TraceId Identifier to correlate the problem with logs and traces
+	TraceId *string `json:"traceId,omitempty"`
+
+	// Type URI identifying the problem type, one per error code
+	Type string `json:"type"`
+}
+
+// PromptTemplateDTO defines model for PromptTemplateDTO.
+type PromptTemplateDTO struct {
+	// CreatedAt Date when the latest version was created
+	CreatedAt *LocalDateTime `json:"createdAt,omitempty"`
+
+	// Name Name of the template, used as promptTemplate when creating a chat
+	Name *string `json:"name,omitempty"`
+
+	// Version Latest version of the template
+	Version *int32 `json:"version,omitempty"`
+}
+
+// PromptVersionDTO System prompt template version a BACKEND message was rendered from, or that was in effect for an LLM reply
+type PromptVersionDTO struct {
+	// Name Name of the template
+	Name *string `json:"name,omitempty"`
+
+	// Version Version of the template
+	Version *int32 `json:"version,omitempty"`
+}
+
+// PurgeReportDTO defines model for PurgeReportDTO.
+type PurgeReportDTO struct {
+	// Runs The latest runs, newest first
+	Runs []PurgeRunDTO `json:"runs"`
+
+	// Totals Rows removed by all runs that were not dry runs
+	Totals PurgeTotalsDTO `json:"totals"`
+}
+
+// PurgeRunDTO defines model for PurgeRunDTO.
+type PurgeRunDTO struct {
+	// DeletedChats Number of chats deleted by their user that were purged
+	DeletedChats int32 `json:"deletedChats"`
+
+	// DryRun Whether the run only counted what it would remove
+	DryRun bool `json:"dryRun"`
+
+	// Error Why the run stopped early, omitted if it completed. The counts include the batches purged before.
+	Error *string `json:"error,omitempty"`
+
+	// ExpiredMessages Number of messages older than the message retention period removed from chats that were kept
+	ExpiredMessages int32              `json:"expiredMessages"`
+	FinishedAt      LocalDateTime      `json:"finishedAt"`
+	Id              openapi_types.UUID `json:"id"`
+
+	// InactiveChats Number of chats inactive for longer than the retention period that were purged
+	InactiveChats int32 `json:"inactiveChats"`
+
+	// Messages Number of messages purged with the chats
+	Messages  int32         `json:"messages"`
+	StartedAt LocalDateTime `json:"startedAt"`
+}
+
+// PurgeTotalsDTO Rows removed by all runs that were not dry runs
+type PurgeTotalsDTO struct {
+	// DeletedChats Number of purged chats deleted by their user
+	DeletedChats int64 `json:"deletedChats"`
+
+	// ExpiredMessages Number of messages older than the message retention period removed from chats that were kept
+	ExpiredMessages int64 `json:"expiredMessages"`
+
+	// InactiveChats Number of purged chats inactive for longer than the retention period
+	InactiveChats int64 `json:"inactiveChats"`
+
+	// Messages Number of messages purged with the chats
+	Messages int64 `json:"messages"`
+
+	// Runs Number of runs that were not dry runs
+	Runs int64 `json:"runs"`
+}
+
+// QuotaDTO defines model for QuotaDTO.
+type QuotaDTO struct {
+	// Limit Monthly token quota of the user, 0 means unlimited
+	Limit *int64 `json:"limit,omitempty"`
+
+	// Remaining Tokens left in the current month, omitted if unlimited
+	Remaining *int64 `json:"remaining,omitempty"`
+
+	// ResetsAt Date when the quota resets
+	ResetsAt *LocalDateTime `json:"resetsAt,omitempty"`
+
+	// TenantLimit Monthly token quota of the tenant, omitted if unlimited
+	TenantLimit *int64 `json:"tenantLimit,omitempty"`
+
+	// TenantUsed Tokens consumed by the whole tenant in the current month, omitted if the tenant is unlimited
+	TenantUsed *int64 `json:"tenantUsed,omitempty"`
+
+	// Used Tokens consumed by the user in the current month
+	Used *int64 `json:"used,omitempty"`
+}
+
+// SearchHitDTO defines model for SearchHitDTO.
+type SearchHitDTO struct {
+	ChatId    *openapi_types.UUID `json:"chatId,omitempty"`
+	ChatTitle *string             `json:"chatTitle,omitempty"`
+	CreatedAt *LocalDateTime      `json:"createdAt,omitempty"`
+
+	// MessageId Matching message, omitted if the chat title matched
+	MessageId *openapi_types.UUID `json:"messageId,omitempty"`
+
+	// Rank Relevance of the hit, higher is better
+	Rank *float32 `json:"rank,omitempty"`
 
 	// SenderType Type of sender (automatically set to 'user' for user messages)
 	SenderType *SenderType `json:"senderType,omitempty"`
+
+	// Snippet HTML-escaped excerpt of the title or message, matched words are wrapped in <mark> tags
+	Snippet *string `json:"snippet,omitempty"`
+}
+
+// SearchResultDTO defines model for SearchResultDTO.
+type SearchResultDTO struct {
+	// HasMore Whether there are more hits on the next page
+	HasMore  *bool           `json:"hasMore,omitempty"`
+	Hits     *[]SearchHitDTO `json:"hits,omitempty"`
+	Page     *int32          `json:"page,omitempty"`
+	PageSize *int32          `json:"pageSize,omitempty"`
 }
 
 // SenderType Type of sender (automatically set to 'user' for user messages)
 type SenderType string
 
+// SourceDTO defines model for SourceDTO.
+type SourceDTO struct {
+	// DocumentId Knowledge base document the excerpt is taken from, missing if the document was deleted
+	DocumentId *openapi_types.UUID `json:"documentId,omitempty"`
+
+	// Excerpt Beginning of the excerpt passed to the LLM
+	Excerpt *string `json:"excerpt,omitempty"`
+
+	// Score Similarity of the excerpt to the user message, between 0 and 1
+	Score *float64 `json:"score,omitempty"`
+
+	// Title Title of the document
+	Title *string `json:"title,omitempty"`
+}
+
+// UsageDTO defines model for UsageDTO.
+type UsageDTO struct {
+	// Chats Tokens consumed per chat, highest consumption first
+	Chats *[]ChatUsageDTO `json:"chats,omitempty"`
+
+	// CompletionTokens Completion tokens consumed in the date range
+	CompletionTokens *int64 `json:"completionTokens,omitempty"`
+
+	// Daily Tokens consumed per day, days without usage are omitted
+	Daily *[]DailyUsageDTO `json:"daily,omitempty"`
+
+	// From First day included (UTC)
+	From *openapi_types.Date `json:"from,omitempty"`
+
+	// PromptTokens Prompt tokens consumed in the date range
+	PromptTokens *int64    `json:"promptTokens,omitempty"`
+	Quota        *QuotaDTO `json:"quota,omitempty"`
+
+	// To Last day included (UTC)
+	To *openapi_types.Date `json:"to,omitempty"`
+
+	// TotalTokens Prompt and completion tokens consumed in the date range
+	TotalTokens *int64 `json:"totalTokens,omitempty"`
+}
+
+// ImportChatsParams defines parameters for ImportChats.
+type ImportChatsParams struct {
+	// Format Format of the archive, detected from its structure if missing
+	Format *ImportChatsParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+
+	// UserEmail User the chats are imported for, required for ChatGPT archives. Overrides the userEmail of Node.js chats.
+	UserEmail *openapi_types.Email `form:"userEmail,omitempty" json:"userEmail,omitempty"`
+}
+
+// ImportChatsParamsFormat defines parameters for ImportChats.
+type ImportChatsParamsFormat string
+
+// UploadDocumentMultipartBody defines parameters for UploadDocument.
+type UploadDocumentMultipartBody struct {
+	// File The document, its file name identifies the document
+	File openapi_types.File `json:"file"`
+}
+
+// GetPurgeRunsParams defines parameters for GetPurgeRuns.
+type GetPurgeRunsParams struct {
+	// Limit Number of runs to return
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetChatsParams defines parameters for GetChats.
+type GetChatsParams struct {
+	// Cursor Position after the last chat of the previous page, taken from its nextCursor
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit Number of chats per page
+	Limit *int32 `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Sort Date the chats are sorted and filtered by, newest first
+	Sort *GetChatsParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// From Only return chats whose sort date is at or after this time
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Only return chats whose sort date is before this time
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Title Only return chats whose title contains this text, ignoring case
+	Title *string `form:"title,omitempty" json:"title,omitempty"`
+
+	// Archived Return the archived chats instead of the others
+	Archived *bool `form:"archived,omitempty" json:"archived,omitempty"`
+
+	// Pinned Only return pinned chats if true or chats that are not pinned if false
+	Pinned *bool `form:"pinned,omitempty" json:"pinned,omitempty"`
+}
+
+// GetChatsParamsSort defines parameters for GetChats.
+type GetChatsParamsSort string
+
 // CreateChatJSONBody defines parameters for CreateChat.
 type CreateChatJSONBody struct {
 	// Content Content of the first message to start the chat with
 	Content string `json:"content"`
+
+	// Locale Locale passed to the system prompt template, defaults to the Accept-Language header
+	Locale *string `json:"locale,omitempty"`
+
+	// PromptTemplate Name of the system prompt template for the chat, defaults to the template configured for the deployment
+	PromptTemplate *string `json:"promptTemplate,omitempty"`
+}
+
+// UpdateChatJSONBody defines parameters for UpdateChat.
+type UpdateChatJSONBody struct {
+	// Archived Move the chat out of the chat list into the archive
+	Archived *bool `json:"archived,omitempty"`
+
+	// Pinned Pin the chat to the top of the chat list
+	Pinned *bool `json:"pinned,omitempty"`
+
+	// Title New title of the chat
+	Title *string `json:"title,omitempty"`
+}
+
+// SwitchBranchJSONBody defines parameters for SwitchBranch.
+type SwitchBranchJSONBody struct {
+	// MessageId Message on the branch to switch to, usually the leafId of a branch
+	MessageId openapi_types.UUID `json:"messageId"`
+}
+
+// ExportChatParams defines parameters for ExportChat.
+type ExportChatParams struct {
+	// Format Format of the transcript
+	Format *ExportChatParamsFormat `form:"format,omitempty" json:"format,omitempty"`
 }
 
+// ExportChatParamsFormat defines parameters for ExportChat.
+type ExportChatParamsFormat string
+
 // CreateMessageJSONBody defines parameters for CreateMessage.
 type CreateMessageJSONBody struct {
 	// Content Content of the message
 	Content string `json:"content"`
 }
 
+// EditMessageJSONBody defines parameters for EditMessage.
+type EditMessageJSONBody struct {
+	// Content Edited content of the message
+	Content string `json:"content"`
+}
+
+// SearchChatsParams defines parameters for SearchChats.
+type SearchChatsParams struct {
+	// Q Search query
+	Q string `form:"q" json:"q"`
+
+	// Page Page of hits, starting at 1
+	Page *int32 `form:"page,omitempty" json:"page,omitempty"`
+
+	// PageSize Number of hits per page
+	PageSize *int32 `form:"pageSize,omitempty" json:"pageSize,omitempty"`
+}
+
+// GetUsageParams defines parameters for GetUsage.
+type GetUsageParams struct {
+	// From First day to include (UTC), defaults to the start of the current month
+	From *openapi_types.Date `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Last day to include (UTC), defaults to today
+	To *openapi_types.Date `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// ImportChatsJSONRequestBody defines body for ImportChats for application/json ContentType.
+type ImportChatsJSONRequestBody = ChatArchive
+
+// UploadDocumentMultipartRequestBody defines body for UploadDocument for multipart/form-data ContentType.
+type UploadDocumentMultipartRequestBody UploadDocumentMultipartBody
+
 // CreateChatJSONRequestBody defines body for CreateChat for application/json ContentType.
 type CreateChatJSONRequestBody CreateChatJSONBody
 
+// UpdateChatJSONRequestBody defines body for UpdateChat for application/json ContentType.
+type UpdateChatJSONRequestBody UpdateChatJSONBody
+
+// SwitchBranchJSONRequestBody defines body for SwitchBranch for application/json ContentType.
+type SwitchBranchJSONRequestBody SwitchBranchJSONBody
+
 // CreateMessageJSONRequestBody defines body for CreateMessage for application/json ContentType.
 type CreateMessageJSONRequestBody CreateMessageJSONBody
 
+// EditMessageJSONRequestBody defines body for EditMessage for application/json ContentType.
+type EditMessageJSONRequestBody EditMessageJSONBody
+
+// HandleMcpMessageJSONRequestBody defines body for HandleMcpMessage for application/json ContentType.
+type HandleMcpMessageJSONRequestBody = JsonRpcMessage
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// Get all chats for a user
+	// Import chats from an archive
+	// (POST /v1/admin/chat-imports)
+	ImportChats(c *fiber.Ctx, params ImportChatsParams) error
+	// Get the knowledge base documents
+	// (GET /v1/admin/documents)
+	GetDocuments(c *fiber.Ctx) error
+	// Upload a document
+	// (POST /v1/admin/documents)
+	UploadDocument(c *fiber.Ctx) error
+	// Delete a document
+	// (DELETE /v1/admin/documents/{documentId})
+	DeleteDocument(c *fiber.Ctx, documentId openapi_types.UUID) error
+	// Re-index a document
+	// (POST /v1/admin/documents/{documentId}/reindex)
+	ReindexDocument(c *fiber.Ctx, documentId openapi_types.UUID) error
+	// Get the latest ingestion jobs
+	// (GET /v1/admin/ingestion-jobs)
+	GetIngestionJobs(c *fiber.Ctx) error
+	// Get an ingestion job
+	// (GET /v1/admin/ingestion-jobs/{jobId})
+	GetIngestionJob(c *fiber.Ctx, jobId openapi_types.UUID) error
+	// Get the latest purge runs
+	// (GET /v1/admin/purge-runs)
+	GetPurgeRuns(c *fiber.Ctx, params GetPurgeRunsParams) error
+	// Erase the data of a user
+	// (DELETE /v1/admin/users/{userEmail}/data)
+	EraseUserData(c *fiber.Ctx, userEmail openapi_types.Email) error
+	// Export the data of a user
+	// (GET /v1/admin/users/{userEmail}/data)
+	ExportUserData(c *fiber.Ctx, userEmail openapi_types.Email) error
+	// Get the chats of a user
 	// (GET /v1/chats)
-	GetChats(c *fiber.Ctx) error
+	GetChats(c *fiber.Ctx, params GetChatsParams) error
 	// Create a new chat
 	// (POST /v1/chats)
 	CreateChat(c *fiber.Ctx) error
+	// Delete a chat
+	// (DELETE /v1/chats/{chatId})
+	DeleteChat(c *fiber.Ctx, chatId openapi_types.UUID) error
+	// Update a chat
+	// (PATCH /v1/chats/{chatId})
+	UpdateChat(c *fiber.Ctx, chatId openapi_types.UUID) error
+	// List the branches of a chat
+	// (GET /v1/chats/{chatId}/branches)
+	GetBranches(c *fiber.Ctx, chatId openapi_types.UUID) error
+	// Switch the active branch
+	// (PUT /v1/chats/{chatId}/branches/active)
+	SwitchBranch(c *fiber.Ctx, chatId openapi_types.UUID) error
+	// Export a chat
+	// (GET /v1/chats/{chatId}/export)
+	ExportChat(c *fiber.Ctx, chatId openapi_types.UUID, params ExportChatParams) error
 	// Get all messages for a chat
 	// (GET /v1/chats/{chatId}/messages)
-	GetMessages(c *fiber.Ctx, chatId int) error
+	GetMessages(c *fiber.Ctx, chatId openapi_types.UUID) error
 	// Create a new message
 	// (POST /v1/chats/{chatId}/messages)
-	CreateMessage(c *fiber.Ctx, chatId int) error
+	CreateMessage(c *fiber.Ctx, chatId openapi_types.UUID) error
+	// Edit a user message
+	// (POST /v1/chats/{chatId}/messages/{messageId}/edit)
+	EditMessage(c *fiber.Ctx, chatId openapi_types.UUID, messageId openapi_types.UUID) error
+	// Regenerate an LLM reply
+	// (POST /v1/chats/{chatId}/messages/{messageId}/regenerate)
+	RegenerateMessage(c *fiber.Ctx, chatId openapi_types.UUID, messageId openapi_types.UUID) error
+	// Stream chat events
+	// (GET /v1/events)
+	StreamEvents(c *fiber.Ctx) error
+	// Open an MCP event stream
+	// (GET /v1/mcp)
+	OpenMcpStream(c *fiber.Ctx) error
+	// Send an MCP message
+	// (POST /v1/mcp)
+	HandleMcpMessage(c *fiber.Ctx) error
+	// Erase the data of the user
+	// (DELETE /v1/me/data)
+	EraseMyData(c *fiber.Ctx) error
+	// Export the data of the user
+	// (GET /v1/me/data)
+	ExportMyData(c *fiber.Ctx) error
+	// Get the system prompt templates
+	// (GET /v1/prompt-templates)
+	GetPromptTemplates(c *fiber.Ctx) error
+	// Search the chats of the user
+	// (GET /v1/search)
+	SearchChats(c *fiber.Ctx, params SearchChatsParams) error
+	// Get the token usage of the user
+	// (GET /v1/usage)
+	GetUsage(c *fiber.Ctx, params GetUsageParams) error
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -125,25 +875,341 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc fiber.Handler
 
-// GetChats operation middleware
-func (siw *ServerInterfaceWrapper) GetChats(c *fiber.Ctx) error {
-
-	return siw.Handler.GetChats(c)
-}
+// ImportChats operation middleware
+func (siw *ServerInterfaceWrapper) ImportChats(c *fiber.Ctx) error {
 
-// CreateChat operation middleware
-func (siw *ServerInterfaceWrapper) CreateChat(c *fiber.Ctx) error {
+	var err error
 
-	return siw.Handler.CreateChat(c)
-}
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ImportChatsParams
 
-// GetMessages operation middleware
-func (siw *ServerInterfaceWrapper) GetMessages(c *fiber.Ctx) error {
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
 
-	var err error
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", query, &params.Format)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter format: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "userEmail" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "userEmail", query, &params.UserEmail)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter userEmail: %w", err).Error())
+	}
+
+	return siw.Handler.ImportChats(c, params)
+}
+
+// GetDocuments operation middleware
+func (siw *ServerInterfaceWrapper) GetDocuments(c *fiber.Ctx) error {
+
+	return siw.Handler.GetDocuments(c)
+}
+
+// UploadDocument operation middleware
+func (siw *ServerInterfaceWrapper) UploadDocument(c *fiber.Ctx) error {
+
+	return siw.Handler.UploadDocument(c)
+}
+
+// DeleteDocument operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDocument(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", c.Params("documentId"), &documentId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter documentId: %w", err).Error())
+	}
+
+	return siw.Handler.DeleteDocument(c, documentId)
+}
+
+// ReindexDocument operation middleware
+func (siw *ServerInterfaceWrapper) ReindexDocument(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", c.Params("documentId"), &documentId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter documentId: %w", err).Error())
+	}
+
+	return siw.Handler.ReindexDocument(c, documentId)
+}
+
+// GetIngestionJobs operation middleware
+func (siw *ServerInterfaceWrapper) GetIngestionJobs(c *fiber.Ctx) error {
+
+	return siw.Handler.GetIngestionJobs(c)
+}
+
+// GetIngestionJob operation middleware
+func (siw *ServerInterfaceWrapper) GetIngestionJob(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "jobId" -------------
+	var jobId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "jobId", c.Params("jobId"), &jobId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter jobId: %w", err).Error())
+	}
+
+	return siw.Handler.GetIngestionJob(c, jobId)
+}
+
+// GetPurgeRuns operation middleware
+func (siw *ServerInterfaceWrapper) GetPurgeRuns(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetPurgeRunsParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	return siw.Handler.GetPurgeRuns(c, params)
+}
+
+// EraseUserData operation middleware
+func (siw *ServerInterfaceWrapper) EraseUserData(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "userEmail" -------------
+	var userEmail openapi_types.Email
+
+	err = runtime.BindStyledParameterWithOptions("simple", "userEmail", c.Params("userEmail"), &userEmail, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter userEmail: %w", err).Error())
+	}
+
+	return siw.Handler.EraseUserData(c, userEmail)
+}
+
+// ExportUserData operation middleware
+func (siw *ServerInterfaceWrapper) ExportUserData(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "userEmail" -------------
+	var userEmail openapi_types.Email
+
+	err = runtime.BindStyledParameterWithOptions("simple", "userEmail", c.Params("userEmail"), &userEmail, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter userEmail: %w", err).Error())
+	}
+
+	return siw.Handler.ExportUserData(c, userEmail)
+}
+
+// GetChats operation middleware
+func (siw *ServerInterfaceWrapper) GetChats(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetChatsParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", query, &params.Cursor)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter cursor: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", query, &params.Sort)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter sort: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "title" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "title", query, &params.Title)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter title: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "archived" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "archived", query, &params.Archived)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter archived: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "pinned" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "pinned", query, &params.Pinned)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter pinned: %w", err).Error())
+	}
+
+	return siw.Handler.GetChats(c, params)
+}
+
+// CreateChat operation middleware
+func (siw *ServerInterfaceWrapper) CreateChat(c *fiber.Ctx) error {
+
+	return siw.Handler.CreateChat(c)
+}
+
+// DeleteChat operation middleware
+func (siw *ServerInterfaceWrapper) DeleteChat(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	return siw.Handler.DeleteChat(c, chatId)
+}
+
+// UpdateChat operation middleware
+func (siw *ServerInterfaceWrapper) UpdateChat(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	return siw.Handler.UpdateChat(c, chatId)
+}
+
+// GetBranches operation middleware
+func (siw *ServerInterfaceWrapper) GetBranches(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	return siw.Handler.GetBranches(c, chatId)
+}
+
+// SwitchBranch operation middleware
+func (siw *ServerInterfaceWrapper) SwitchBranch(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	return siw.Handler.SwitchBranch(c, chatId)
+}
+
+// ExportChat operation middleware
+func (siw *ServerInterfaceWrapper) ExportChat(c *fiber.Ctx) error {
+
+	var err error
 
 	// ------------- Path parameter "chatId" -------------
-	var chatId int
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportChatParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", query, &params.Format)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter format: %w", err).Error())
+	}
+
+	return siw.Handler.ExportChat(c, chatId, params)
+}
+
+// GetMessages operation middleware
+func (siw *ServerInterfaceWrapper) GetMessages(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
 
 	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
 	if err != nil {
@@ -159,7 +1225,7 @@ func (siw *ServerInterfaceWrapper) CreateMessage(c *fiber.Ctx) error {
 	var err error
 
 	// ------------- Path parameter "chatId" -------------
-	var chatId int
+	var chatId openapi_types.UUID
 
 	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
 	if err != nil {
@@ -169,6 +1235,167 @@ func (siw *ServerInterfaceWrapper) CreateMessage(c *fiber.Ctx) error {
 	return siw.Handler.CreateMessage(c, chatId)
 }
 
+// EditMessage operation middleware
+func (siw *ServerInterfaceWrapper) EditMessage(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	// ------------- Path parameter "messageId" -------------
+	var messageId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "messageId", c.Params("messageId"), &messageId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter messageId: %w", err).Error())
+	}
+
+	return siw.Handler.EditMessage(c, chatId, messageId)
+}
+
+// RegenerateMessage operation middleware
+func (siw *ServerInterfaceWrapper) RegenerateMessage(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "chatId" -------------
+	var chatId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "chatId", c.Params("chatId"), &chatId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter chatId: %w", err).Error())
+	}
+
+	// ------------- Path parameter "messageId" -------------
+	var messageId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "messageId", c.Params("messageId"), &messageId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter messageId: %w", err).Error())
+	}
+
+	return siw.Handler.RegenerateMessage(c, chatId, messageId)
+}
+
+// StreamEvents operation middleware
+func (siw *ServerInterfaceWrapper) StreamEvents(c *fiber.Ctx) error {
+
+	return siw.Handler.StreamEvents(c)
+}
+
+// OpenMcpStream operation middleware
+func (siw *ServerInterfaceWrapper) OpenMcpStream(c *fiber.Ctx) error {
+
+	return siw.Handler.OpenMcpStream(c)
+}
+
+// HandleMcpMessage operation middleware
+func (siw *ServerInterfaceWrapper) HandleMcpMessage(c *fiber.Ctx) error {
+
+	return siw.Handler.HandleMcpMessage(c)
+}
+
+// EraseMyData operation middleware
+func (siw *ServerInterfaceWrapper) EraseMyData(c *fiber.Ctx) error {
+
+	return siw.Handler.EraseMyData(c)
+}
+
+// ExportMyData operation middleware
+func (siw *ServerInterfaceWrapper) ExportMyData(c *fiber.Ctx) error {
+
+	return siw.Handler.ExportMyData(c)
+}
+
+// GetPromptTemplates operation middleware
+func (siw *ServerInterfaceWrapper) GetPromptTemplates(c *fiber.Ctx) error {
+
+	return siw.Handler.GetPromptTemplates(c)
+}
+
+// SearchChats operation middleware
+func (siw *ServerInterfaceWrapper) SearchChats(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchChatsParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Required query parameter "q" -------------
+
+	if paramValue := c.Query("q"); paramValue != "" {
+
+	} else {
+		err = fmt.Errorf("Query argument q is required, but not found")
+		c.Status(fiber.StatusBadRequest).JSON(err)
+		return err
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "q", query, &params.Q)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter q: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "page" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page", query, &params.Page)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter page: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "pageSize" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "pageSize", query, &params.PageSize)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter pageSize: %w", err).Error())
+	}
+
+	return siw.Handler.SearchChats(c, params)
+}
+
+// GetUsage operation middleware
+func (siw *ServerInterfaceWrapper) GetUsage(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUsageParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	return siw.Handler.GetUsage(c, params)
+}
+
 // FiberServerOptions provides options for the Fiber server.
 type FiberServerOptions struct {
 	BaseURL     string
@@ -190,12 +1417,62 @@ func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, option
 		router.Use(fiber.Handler(m))
 	}
 
+	router.Post(options.BaseURL+"/v1/admin/chat-imports", wrapper.ImportChats)
+
+	router.Get(options.BaseURL+"/v1/admin/documents", wrapper.GetDocuments)
+
+	router.Post(options.BaseURL+"/v1/admin/documents", wrapper.UploadDocument)
+
+	router.Delete(options.BaseURL+"/v1/admin/documents/:documentId", wrapper.DeleteDocument)
+
+	router.Post(options.BaseURL+"/v1/admin/documents/:documentId/reindex", wrapper.ReindexDocument)
+
+	router.Get(options.BaseURL+"/v1/admin/ingestion-jobs", wrapper.GetIngestionJobs)
+
+	router.Get(options.BaseURL+"/v1/admin/ingestion-jobs/:jobId", wrapper.GetIngestionJob)
+
+	router.Get(options.BaseURL+"/v1/admin/purge-runs", wrapper.GetPurgeRuns)
+
+	router.Delete(options.BaseURL+"/v1/admin/users/:userEmail/data", wrapper.EraseUserData)
+
+	router.Get(options.BaseURL+"/v1/admin/users/:userEmail/data", wrapper.ExportUserData)
+
 	router.Get(options.BaseURL+"/v1/chats", wrapper.GetChats)
 
 	router.Post(options.BaseURL+"/v1/chats", wrapper.CreateChat)
 
+	router.Delete(options.BaseURL+"/v1/chats/:chatId", wrapper.DeleteChat)
+
+	router.Patch(options.BaseURL+"/v1/chats/:chatId", wrapper.UpdateChat)
+
+	router.Get(options.BaseURL+"/v1/chats/:chatId/branches", wrapper.GetBranches)
+
+	router.Put(options.BaseURL+"/v1/chats/:chatId/branches/active", wrapper.SwitchBranch)
+
+	router.Get(options.BaseURL+"/v1/chats/:chatId/export", wrapper.ExportChat)
+
 	router.Get(options.BaseURL+"/v1/chats/:chatId/messages", wrapper.GetMessages)
 
 	router.Post(options.BaseURL+"/v1/chats/:chatId/messages", wrapper.CreateMessage)
 
+	router.Post(options.BaseURL+"/v1/chats/:chatId/messages/:messageId/edit", wrapper.EditMessage)
+
+	router.Post(options.BaseURL+"/v1/chats/:chatId/messages/:messageId/regenerate", wrapper.RegenerateMessage)
+
+	router.Get(options.BaseURL+"/v1/events", wrapper.StreamEvents)
+
+	router.Get(options.BaseURL+"/v1/mcp", wrapper.OpenMcpStream)
+
+	router.Post(options.BaseURL+"/v1/mcp", wrapper.HandleMcpMessage)
+
+	router.Delete(options.BaseURL+"/v1/me/data", wrapper.EraseMyData)
+
+	router.Get(options.BaseURL+"/v1/me/data", wrapper.ExportMyData)
+
+	router.Get(options.BaseURL+"/v1/prompt-templates", wrapper.GetPromptTemplates)
+
+	router.Get(options.BaseURL+"/v1/search", wrapper.SearchChats)
+
+	router.Get(options.BaseURL+"/v1/usage", wrapper.GetUsage)
+
 }
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/opensearch"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (s *ChatServer) GetBranches(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return err
+	}
+	return c.JSON(tree.branches())
+}
+
+func (s *ChatServer) SwitchBranch(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	var body SwitchBranchJSONRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	message, err := s.getChatMessage(ctx, chat, body.MessageId)
+	if err != nil {
+		return err
+	}
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return err
+	}
+	// Tool call records are shown with their message, switching to one switches to the message
+	if tree.isAttachment(message) {
+		message = tree.byID[message.ParentID.UUID]
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to switch branch")
+	}
+	defer tx.Rollback()
+	if err := activatePath(ctx, s.Store.WithTx(tx), tree, message.ID, time.Now().UTC()); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to switch branch")
+	}
+	if err := tx.Commit(); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to switch branch")
+	}
+
+	tree, err = s.messageTree(ctx, chat)
+	if err != nil {
+		return err
+	}
+	dtos, err := s.messageDTOs(ctx, tree.activePath())
+	if err != nil {
+		return err
+	}
+	return c.JSON(dtos)
+}
+
+func (s *ChatServer) EditMessage(c *fiber.Ctx, chatId uuid.UUID, messageId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	var body EditMessageJSONRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	original, err := s.getChatMessage(ctx, chat, messageId)
+	if err != nil {
+		return err
+	}
+	if original.SenderType != senderTypeUser {
+		return apperrors.NewAppError(apperrors.ConflictError, "Only user messages can be edited")
+	}
+
+	if err := s.checkQuota(ctx, user); err != nil {
+		return err
+	}
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return err
+	}
+
+	// The edited message is a sibling of the original, so the conversation forks before it
+	now := time.Now().UTC()
+	message, err := s.storeMessage(ctx, tree, database.CreateMessageParams{
+		ID:         uuid.New(),
+		Content:    body.Content,
+		SenderType: senderTypeUser,
+		ChatID:     chat.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ParentID:   original.ParentID,
+	}, nil)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create message")
+	}
+	s.Events.Index(opensearch.MessageSent(user.Email, chat.ID, message.ID))
+
+	reply, err := s.generateReply(ctx, user, chat, message)
+	if err != nil {
+		return err
+	}
+	dtos, err := s.messageDTOs(ctx, []database.Message{reply})
+	if err != nil {
+		return err
+	}
+	return c.JSON(dtos[0])
+}
+
+// messageTree loads all messages of the chat including the inactive branches
+func (s *ChatServer) messageTree(ctx context.Context, chat database.Chat) (*messageTree, error) {
+	messages, err := s.Store.GetMessagesByChatID(ctx, chat.ID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch messages")
+	}
+	return newMessageTree(messages), nil
+}
+
+// storeMessage stores a message as the active one among its siblings. The path to its parent becomes the
+// active conversation and the records of the tool calls made for the message are moved below it.
+func (s *ChatServer) storeMessage(ctx context.Context, tree *messageTree, params database.CreateMessageParams, toolCalls []uuid.UUID) (database.Message, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Message{}, err
+	}
+	defer tx.Rollback()
+	qtx := s.Store.WithTx(tx)
+
+	// Regenerating or editing the same message twice at once would number both variants the same
+	if err := qtx.LockChat(ctx, params.ChatID); err != nil {
+		return database.Message{}, err
+	}
+	if params.ParentID.Valid {
+		if err := activatePath(ctx, qtx, tree, params.ParentID.UUID, params.CreatedAt); err != nil {
+			return database.Message{}, err
+		}
+	}
+	err = qtx.DeactivateSiblings(ctx, database.DeactivateSiblingsParams{
+		UpdatedAt: params.UpdatedAt,
+		ChatID:    params.ChatID,
+		ParentID:  params.ParentID,
+		ID:        params.ID,
+	})
+	if err != nil {
+		return database.Message{}, err
+	}
+	message, err := qtx.CreateMessage(ctx, params)
+	if err != nil {
+		return database.Message{}, err
+	}
+	if len(toolCalls) > 0 {
+		err = qtx.AttachToolCalls(ctx, database.AttachToolCallsParams{
+			ReplyID: uuid.NullUUID{UUID: message.ID, Valid: true},
+			Ids:     toolCalls,
+		})
+		if err != nil {
+			return database.Message{}, err
+		}
+	}
+	return message, tx.Commit()
+}
+
+// activatePath makes the message and its ancestors the active ones among their siblings
+func activatePath(ctx context.Context, qtx *database.Queries, tree *messageTree, id uuid.UUID, now time.Time) error {
+	for _, message := range tree.ancestors(id) {
+		// Active messages already are the only active ones among their siblings
+		if message.Active {
+			continue
+		}
+		err := qtx.ActivateMessage(ctx, database.ActivateMessageParams{ID: message.ID, UpdatedAt: now})
+		if err != nil {
+			return err
+		}
+		err = qtx.DeactivateSiblings(ctx, database.DeactivateSiblingsParams{
+			UpdatedAt: now,
+			ChatID:    message.ChatID,
+			ParentID:  message.ParentID,
+			ID:        message.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageTree holds the messages of a chat. Every message points to the message it follows, edited questions
+// and regenerated replies are siblings of the original. The conversation is the path along the active messages,
+// tool call records are attached to the message they were made for and shown with it.
+type messageTree struct {
+	byID     map[uuid.UUID]database.Message
+	children map[uuid.UUID][]database.Message
+}
+
+// newMessageTree builds the tree from the messages of a chat ordered by creation time
+func newMessageTree(messages []database.Message) *messageTree {
+	tree := &messageTree{
+		byID:     make(map[uuid.UUID]database.Message, len(messages)),
+		children: make(map[uuid.UUID][]database.Message),
+	}
+	for _, message := range messages {
+		tree.byID[message.ID] = message
+		// Roots are the children of uuid.Nil
+		tree.children[message.ParentID.UUID] = append(tree.children[message.ParentID.UUID], message)
+	}
+	return tree
+}
+
+// isAttachment reports whether the message is a tool call record, the system prompt is the only backend
+// message without parent
+func (t *messageTree) isAttachment(message database.Message) bool {
+	return message.SenderType == senderTypeBackend && message.ParentID.Valid
+}
+
+// continuations returns the messages that can follow the message in a conversation, uuid.Nil for the first messages
+func (t *messageTree) continuations(id uuid.UUID) []database.Message {
+	var next []database.Message
+	for _, child := range t.children[id] {
+		if !t.isAttachment(child) {
+			next = append(next, child)
+		}
+	}
+	return next
+}
+
+// activeChild returns the continuation of the conversation after the message, the latest one if none is active
+func (t *messageTree) activeChild(id uuid.UUID) (database.Message, bool) {
+	next := t.continuations(id)
+	if len(next) == 0 {
+		return database.Message{}, false
+	}
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i].Active {
+			return next[i], true
+		}
+	}
+	return next[len(next)-1], true
+}
+
+// descend follows the active messages from the message to the end of the conversation
+func (t *messageTree) descend(id uuid.UUID) []database.Message {
+	var chain []database.Message
+	for {
+		child, ok := t.activeChild(id)
+		if !ok {
+			return chain
+		}
+		chain = append(chain, child)
+		id = child.ID
+	}
+}
+
+// ancestors returns the conversation from the first message to the message
+func (t *messageTree) ancestors(id uuid.UUID) []database.Message {
+	var chain []database.Message
+	for message, ok := t.byID[id]; ok; message, ok = t.byID[message.ParentID.UUID] {
+		chain = append(chain, message)
+		if !message.ParentID.Valid {
+			break
+		}
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// withAttachments adds the tool call records to the conversation in the order they were made
+func (t *messageTree) withAttachments(chain []database.Message) []database.Message {
+	messages := make([]database.Message, 0, len(chain))
+	for _, message := range chain {
+		messages = append(messages, message)
+		for _, child := range t.children[message.ID] {
+			if t.isAttachment(child) {
+				messages = append(messages, child)
+			}
+		}
+	}
+	slices.SortStableFunc(messages, func(a, b database.Message) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return messages
+}
+
+// path returns the conversation up to the message as passed to the LLM
+func (t *messageTree) path(id uuid.UUID) []database.Message {
+	return t.withAttachments(t.ancestors(id))
+}
+
+// activePath returns the active conversation of the chat
+func (t *messageTree) activePath() []database.Message {
+	return t.withAttachments(t.descend(uuid.Nil))
+}
+
+// activeLeaf returns the last message of the active conversation, new messages follow it
+func (t *messageTree) activeLeaf() uuid.NullUUID {
+	chain := t.descend(uuid.Nil)
+	if len(chain) == 0 {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: chain[len(chain)-1].ID, Valid: true}
+}
+
+// branches returns every conversation of the chat, the latest first. A branch ends in a message
+// nothing follows yet and forks off the active conversation at its first message not part of it.
+func (t *messageTree) branches() []BranchDTO {
+	active := make(map[uuid.UUID]bool)
+	for _, message := range t.descend(uuid.Nil) {
+		active[message.ID] = true
+	}
+
+	var leaves []database.Message
+	for _, message := range t.byID {
+		if !t.isAttachment(message) && len(t.continuations(message.ID)) == 0 {
+			leaves = append(leaves, message)
+		}
+	}
+	slices.SortFunc(leaves, func(a, b database.Message) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	branches := make([]BranchDTO, 0, len(leaves))
+	for _, leaf := range leaves {
+		chain := t.ancestors(leaf.ID)
+		var fork uuid.NullUUID
+		if index := slices.IndexFunc(chain, func(message database.Message) bool { return !active[message.ID] }); index >= 0 {
+			fork = uuid.NullUUID{UUID: chain[index].ID, Valid: true}
+		}
+		branches = append(branches, toBranchDTO(leaf, active[leaf.ID], len(t.withAttachments(chain)), fork))
+	}
+	return branches
+}
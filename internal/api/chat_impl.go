@@ -1,47 +1,663 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
 	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/importer"
+	"ai-chat-service-go/internal/knowledge"
+	"ai-chat-service-go/internal/mcp"
 	"ai-chat-service-go/internal/middleware"
+	"ai-chat-service-go/internal/opensearch"
+	"ai-chat-service-go/internal/ratelimit"
+	"ai-chat-service-go/internal/services"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // Ensure ChatServer implements ServerInterface
 var _ ServerInterface = (*ChatServer)(nil)
 
+// Sender types as stored in the messages table
+const (
+	senderTypeUser    = "user"
+	senderTypeBackend = "backend"
+	senderTypeLLM     = "llm"
+)
+
+// retryAfterShutdown is the delay clients are asked to wait when a request hits a draining instance
+const retryAfterShutdown = 5 * time.Second
+
+// maxTitleLength is the maximum number of characters of the first message used as chat title
+const maxTitleLength = 80
+
+// maxPreviewLength is the maximum number of characters of the last message shown in chat lists
+const maxPreviewLength = 120
+
+const (
+	defaultChatPageSize = 20
+	maxChatPageSize     = 100
+)
+
 type ChatServer struct {
-	Store *database.Queries
+	DB          *sql.DB
+	Store       *database.Queries
+	LLM         services.LLMProvider
+	Generations *services.Generations
+	Events      *opensearch.Sink
+	Quotas      *services.Quotas
+	Context     *services.ContextWindow
+	Prompts     *services.Prompts
+	Knowledge   *knowledge.Base
+	Tools       *services.ToolRegistry
+	MCP         *mcp.Server
+	Notifier    *services.Notifier
+	Importer    *importer.Importer
+	// DataSubjects answers data subject access and erasure requests
+	DataSubjects *services.DataSubjects
+	// Titles generates the title of new chats, the first message is used if nil
+	Titles *services.Titles
+	// MaxContentLength limits messages sent through MCP, REST requests are limited by the request validator
+	MaxContentLength int
+	// RateLimits holds the rate limit buckets, MessageRate is applied to messages sent through MCP
+	// like the rate limit middleware applies it to the REST routes. Nil disables rate limiting.
+	RateLimits  ratelimit.Store
+	MessageRate ratelimit.Limit
 }
 
-func (s *ChatServer) GetChats(fiberContext *fiber.Ctx) error {
-	// mocked user for now
-	user := middleware.MockUserInfo("paul.naber@gmail.com", []string{"admin"})
-	chats, err := s.Store.GetChatsByUserEmail(fiberContext.Context(), user.Email)
+func (s *ChatServer) GetChats(fiberContext *fiber.Ctx, params GetChatsParams) error {
+	user := currentUser(fiberContext)
+
+	sort, limit := LastActiveDate, int32(defaultChatPageSize)
+	if params.Sort != nil {
+		sort = *params.Sort
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit < 1 || limit > maxChatPageSize {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "limit", Value: "limit must be between 1 and 100"})
+	}
+
+	// Archived chats are hidden unless asked for, one extra chat tells whether there is a next page
+	filter := database.GetChatsByUserEmailParams{
+		UserEmail: user.Email,
+		Archived:  sql.NullBool{Bool: false, Valid: true},
+		SortBy:    chatSortColumn(sort),
+		PageLimit: limit + 1,
+	}
+	if params.Archived != nil {
+		filter.Archived.Bool = *params.Archived
+	}
+	if params.Pinned != nil {
+		filter.Pinned = sql.NullBool{Bool: *params.Pinned, Valid: true}
+	}
+	if params.Title != nil {
+		filter.Title = sql.NullString{String: *params.Title, Valid: true}
+	}
+	if params.From != nil {
+		filter.DateFrom = sql.NullTime{Time: *params.From, Valid: true}
+	}
+	if params.To != nil {
+		filter.DateTo = sql.NullTime{Time: *params.To, Valid: true}
+	}
+	if params.Cursor != nil {
+		cursor, err := decodeChatCursor(*params.Cursor)
+		if err != nil || cursor.Sort != sort {
+			return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+				apperrors.ErrorDetail{Field: "cursor", Value: "The cursor is invalid or belongs to another sort order"})
+		}
+		filter.CursorPinned = sql.NullBool{Bool: cursor.Pinned, Valid: true}
+		filter.CursorDate = sql.NullTime{Time: cursor.Date, Valid: true}
+		filter.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chats, err := s.Store.GetChatsByUserEmail(fiberContext.UserContext(), filter)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch chats")
 	}
-	return fiberContext.JSON(chats)
+	var nextCursor *string
+	if len(chats) > int(limit) {
+		chats = chats[:limit]
+		last := chats[len(chats)-1]
+		cursor := chatCursor{Sort: sort, Pinned: last.Pinned, Date: last.LastActiveDate, ID: last.ID}
+		if sort == CreatedAt {
+			cursor.Date = last.CreatedAt
+		}
+		encoded := encodeChatCursor(cursor)
+		nextCursor = &encoded
+	}
+
+	dtos := toChatListDTOs(chats)
+	return fiberContext.JSON(ChatPageDTO{
+		Chats:      &dtos,
+		NextCursor: nextCursor,
+	})
+}
+
+func (s *ChatServer) UpdateChat(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	var body UpdateChatJSONRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	params := database.UpdateChatParams{
+		ID:        chat.ID,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if body.Title != nil {
+		title := strings.TrimSpace(*body.Title)
+		if title == "" {
+			return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+				apperrors.ErrorDetail{Field: "title", Value: "Title cannot be empty"})
+		}
+		params.Title = sql.NullString{String: title, Valid: true}
+	}
+	if body.Pinned != nil {
+		params.Pinned = sql.NullBool{Bool: *body.Pinned, Valid: true}
+	}
+	if body.Archived != nil {
+		params.Archived = sql.NullBool{Bool: *body.Archived, Valid: true}
+	}
+
+	chat, err = s.Store.UpdateChat(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted in the meantime
+		return fiber.NewError(fiber.StatusNotFound, "The requested chat could not be found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update chat")
+	}
+	s.Notifier.Publish(user.Email, chatUpdated(chat))
+	return c.JSON(toChatDTO(chat))
+}
+
+func (s *ChatServer) DeleteChat(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	// The chat is only marked, the purge job removes it once the retention period is over
+	err = s.Store.DeleteChat(ctx, database.DeleteChatParams{
+		ID:        chat.ID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete chat")
+	}
+	s.Events.Index(opensearch.ChatDeleted(user.Email, chat.ID))
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 func (s *ChatServer) CreateChat(fiberContext *fiber.Ctx) error {
-	// TODO I guess I need a mapper here
-	// because the sqlc generated structs for me to use for querying
-	// oapi-codegen generates the routes and structs for the endpoints
-	// var body CreateChatJSONBody                                            // this is the struct of the openapi spec
-	var mappedBody database.CreateChatParams = database.CreateChatParams{} // this is the struct of the generated sqlc code
-	chat, err := s.Store.CreateChat(fiberContext.Context(), mappedBody)    // Assuming CreateChat(content string) exists
+	user := currentUser(fiberContext)
+
+	var body CreateChatJSONRequestBody
+	if err := fiberContext.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx := fiberContext.UserContext()
+	if err := s.checkQuota(ctx, user); err != nil {
+		return err
+	}
+
+	prompt, err := s.renderSystemPrompt(fiberContext, user, body)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+
+	// The chat and its first message are created together
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create chat")
+	}
+	defer tx.Rollback()
+	qtx := s.Store.WithTx(tx)
+
+	chat, err := qtx.CreateChat(ctx, database.CreateChatParams{
+		ID:             uuid.New(),
+		Title:          chatTitle(body.Content),
+		UserEmail:      user.Email,
+		LastActiveDate: now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Tenant:         user.Tenant,
+	})
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create chat")
 	}
 
-	return fiberContext.JSON(chat)
+	var parentID uuid.NullUUID
+	if prompt != nil {
+		systemPrompt, err := qtx.CreateMessage(ctx, database.CreateMessageParams{
+			ID:               uuid.New(),
+			Content:          prompt.Content,
+			SenderType:       senderTypeBackend,
+			ChatID:           chat.ID,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+			PromptTemplateID: uuid.NullUUID{UUID: prompt.Template.ID, Valid: true},
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create chat")
+		}
+		// Messages are ordered by creation time, the system prompt has to come first
+		now = now.Add(time.Microsecond)
+		parentID = uuid.NullUUID{UUID: systemPrompt.ID, Valid: true}
+	}
+
+	message, err := qtx.CreateMessage(ctx, database.CreateMessageParams{
+		ID:         uuid.New(),
+		Content:    body.Content,
+		SenderType: senderTypeUser,
+		ChatID:     chat.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ParentID:   parentID,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create chat")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create chat")
+	}
+
+	s.Events.Index(opensearch.ChatCreated(user.Email, chat.ID))
+	s.Events.Index(opensearch.MessageSent(user.Email, chat.ID, message.ID))
+
+	reply, err := s.generateReply(ctx, user, chat, message)
+	if err != nil {
+		return err
+	}
+	s.generateTitle(user, chat, message, reply)
+
+	initialMessage := toMessageDTO(message)
+	return fiberContext.JSON(CreateChatResponse{
+		ChatDTO:        toChatDTO(chat),
+		InitialMessage: &initialMessage,
+	})
 }
 
-func (s *ChatServer) CreateMessage(c *fiber.Ctx, chatId int) error {
-	return fiber.NewError(fiber.StatusNotImplemented, "CreateMessage not implemented yet")
+func (s *ChatServer) CreateMessage(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	var body CreateMessageJSONRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	reply, err := s.sendMessage(ctx, user, chat, body.Content)
+	if err != nil {
+		return err
+	}
+
+	dtos, err := s.messageDTOs(ctx, []database.Message{reply})
+	if err != nil {
+		return err
+	}
+	return c.JSON(dtos[0])
 }
 
-func (s *ChatServer) GetMessages(c *fiber.Ctx, chatId int) error {
-	return fiber.NewError(fiber.StatusNotImplemented, "GetMessages not implemented yet")
+func (s *ChatServer) GetMessages(c *fiber.Ctx, chatId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return err
+	}
+
+	dtos, err := s.messageDTOs(ctx, tree.activePath())
+	if err != nil {
+		return err
+	}
+	return c.JSON(dtos)
+}
+
+// sendMessage stores a user message in the chat and returns the reply of the LLM
+func (s *ChatServer) sendMessage(ctx context.Context, user *middleware.UserInfo, chat database.Chat, content string) (database.Message, error) {
+	if err := s.checkQuota(ctx, user); err != nil {
+		return database.Message{}, err
+	}
+
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return database.Message{}, err
+	}
+
+	// The message continues the active branch
+	now := time.Now().UTC()
+	message, err := s.storeMessage(ctx, tree, database.CreateMessageParams{
+		ID:         uuid.New(),
+		Content:    content,
+		SenderType: senderTypeUser,
+		ChatID:     chat.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ParentID:   tree.activeLeaf(),
+	}, nil)
+	if err != nil {
+		return database.Message{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to create message")
+	}
+	s.Events.Index(opensearch.MessageSent(user.Email, chat.ID, message.ID))
+
+	return s.generateReply(ctx, user, chat, message)
+}
+
+// generateReply asks the LLM to answer the conversation up to the message and stores the reply in the chat.
+// The reply becomes the active variant of the replies to the message.
+func (s *ChatServer) generateReply(ctx context.Context, user *middleware.UserInfo, chat database.Chat, message database.Message) (database.Message, error) {
+	tree, err := s.messageTree(ctx, chat)
+	if err != nil {
+		return database.Message{}, err
+	}
+	history := tree.path(message.ID)
+
+	// Register the generation so shutdown waits for it to finish
+	generationCtx, done, err := s.Generations.Start()
+	if err != nil {
+		return database.Message{}, apperrors.NewAppError(apperrors.UnavailableError, "The service is shutting down").WithRetryAfter(retryAfterShutdown)
+	}
+	defer done()
+
+	// Documentation relevant to the user message is passed along with it
+	var injected []services.ChatMessage
+	sources := s.retrieveSources(generationCtx, message)
+	if len(sources) > 0 {
+		injected = append(injected, services.ChatMessage{Role: services.RoleSystem, Content: knowledge.Prompt(sources)})
+	}
+
+	// Older turns are dropped or summarized so the prompt fits into the context window
+	prompt, err := s.Context.Build(generationCtx, chat.ID, services.UsageOwner{Email: user.Email, Tenant: user.Tenant},
+		conversation(history), injected...)
+	if err != nil {
+		return database.Message{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to build prompt")
+	}
+
+	// Stream the answer so a generation cut off at the shutdown deadline still has content to persist
+	var partial strings.Builder
+	start := time.Now()
+	completion, toolCalls, err := s.complete(generationCtx, ctx, user, chat, message, services.CompletionRequest{
+		Messages: prompt,
+		OnDelta:  func(delta string) { partial.WriteString(delta) },
+	})
+	latency := time.Since(start)
+	answer := completion.Content
+	incomplete := false
+	if err != nil {
+		if generationCtx.Err() == nil {
+			return database.Message{}, llmError(s.LLM.Name(), err)
+		}
+		log.Printf("Generation for chat %s interrupted by shutdown, persisting partial reply", chat.ID)
+		answer = partial.String()
+		incomplete = true
+	}
+
+	now := time.Now().UTC()
+	reply, err := s.storeMessage(ctx, tree, database.CreateMessageParams{
+		ID:               uuid.New(),
+		Content:          answer,
+		SenderType:       senderTypeLLM,
+		ChatID:           chat.ID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		Incomplete:       incomplete,
+		PromptTemplateID: promptTemplateID(history),
+		ParentID:         uuid.NullUUID{UUID: message.ID, Valid: true},
+	}, toolCalls)
+	if err != nil {
+		return database.Message{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to store response")
+	}
+
+	err = s.Store.UpdateChatLastActive(ctx, database.UpdateChatLastActiveParams{
+		ID:             chat.ID,
+		LastActiveDate: now,
+		UpdatedAt:      now,
+	})
+	if err != nil {
+		return database.Message{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to update chat")
+	}
+
+	// The reply is already stored, losing its usage only makes the quota more lenient
+	_, err = s.Store.CreateTokenUsage(ctx, database.CreateTokenUsageParams{
+		ID:               uuid.New(),
+		MessageID:        uuid.NullUUID{UUID: reply.ID, Valid: true},
+		ChatID:           uuid.NullUUID{UUID: chat.ID, Valid: true},
+		UserEmail:        user.Email,
+		Tenant:           user.Tenant,
+		Model:            completion.Model,
+		PromptTokens:     int32(completion.PromptTokens),
+		CompletionTokens: int32(completion.CompletionTokens),
+		CreatedAt:        now,
+	})
+	if err != nil {
+		log.Printf("Failed to record token usage for chat %s: %v", chat.ID, err)
+	}
+	s.storeSources(ctx, reply, sources)
+
+	s.Events.Index(opensearch.LLMReply(user.Email, chat.ID, reply.ID, latency))
+	return reply, nil
+}
+
+// messageDTOs maps messages to the API representation including the prompt template versions, sources and variants
+func (s *ChatServer) messageDTOs(ctx context.Context, messages []database.Message) ([]MessageDTO, error) {
+	dtos := toMessageDTOs(messages)
+	if err := s.attachSources(ctx, messages, dtos); err != nil {
+		return nil, err
+	}
+	if err := s.attachVariants(ctx, messages, dtos); err != nil {
+		return nil, err
+	}
+
+	// Chats use one template version, so this is usually a single lookup
+	versions := make(map[uuid.UUID]database.PromptTemplate)
+	for i, message := range messages {
+		if !message.PromptTemplateID.Valid {
+			continue
+		}
+		version, ok := versions[message.PromptTemplateID.UUID]
+		if !ok {
+			var err error
+			version, err = s.Prompts.Get(ctx, message.PromptTemplateID.UUID)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch prompt template")
+			}
+			versions[version.ID] = version
+		}
+		dtos[i].Prompt = toPromptVersionDTO(version)
+	}
+	return dtos, nil
+}
+
+// llmError maps a failed generation to an upstream error for the client
+func llmError(provider string, err error) error {
+	cause := fmt.Errorf("LLM provider %s: %w", provider, err)
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return apperrors.WrapAppError(apperrors.UpstreamTimeoutError, "The AI provider did not respond in time", cause)
+	}
+	return apperrors.WrapAppError(apperrors.UpstreamError, "The AI provider failed to generate a response", cause)
+}
+
+// checkQuota rejects the request if the user or their tenant used up the monthly token quota
+func (s *ChatServer) checkQuota(ctx context.Context, user *middleware.UserInfo) error {
+	status, err := s.Quotas.Check(ctx, user.Email, user.Tenant, user.Roles)
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		return apperrors.NewAppError(apperrors.QuotaExceededError, "The monthly token quota has been used up").
+			WithRetryAfter(time.Until(status.ResetsAt))
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check quota")
+	}
+	return nil
+}
+
+// getOwnedChat loads a chat and makes sure it belongs to the user
+func (s *ChatServer) getOwnedChat(ctx context.Context, user *middleware.UserInfo, chatId uuid.UUID) (database.Chat, error) {
+	chat, err := s.Store.GetChat(ctx, chatId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Chat{}, fiber.NewError(fiber.StatusNotFound, "The requested chat could not be found")
+	}
+	if err != nil {
+		return database.Chat{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch chat")
+	}
+	if chat.UserEmail != user.Email {
+		return database.Chat{}, fiber.NewError(fiber.StatusForbidden, "You do not have permission to access this chat")
+	}
+	return chat, nil
+}
+
+// getChatMessage loads a message of the chat
+func (s *ChatServer) getChatMessage(ctx context.Context, chat database.Chat, messageId uuid.UUID) (database.Message, error) {
+	message, err := s.Store.GetMessage(ctx, messageId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && message.ChatID != chat.ID) {
+		return database.Message{}, fiber.NewError(fiber.StatusNotFound, "The requested message could not be found")
+	}
+	if err != nil {
+		return database.Message{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch message")
+	}
+	return message, nil
+}
+
+// conversation maps the stored messages of a chat to the messages considered for the LLM prompt
+func conversation(messages []database.Message) []services.ContextMessage {
+	result := make([]services.ContextMessage, 0, len(messages))
+	for _, message := range messages {
+		role := services.RoleUser
+		switch message.SenderType {
+		case senderTypeLLM:
+			role = services.RoleAssistant
+		case senderTypeBackend:
+			role = services.RoleSystem
+		}
+		result = append(result, services.ContextMessage{
+			ID:          message.ID,
+			ChatMessage: services.ChatMessage{Role: role, Content: message.Content},
+		})
+	}
+	return result
+}
+
+// currentUser returns the authenticated user, the auth middleware sets it for every request to /v1
+func currentUser(c *fiber.Ctx) *middleware.UserInfo {
+	return middleware.GetCurrentUser(c)
+}
+
+// generateTitle replaces the title derived from the first message with a short title generated by the LLM.
+// It runs in the background after the first exchange, if it fails the first message stays the title.
+func (s *ChatServer) generateTitle(user *middleware.UserInfo, chat database.Chat, question, reply database.Message) {
+	if s.Titles == nil || reply.Incomplete {
+		return
+	}
+	// Registered as generation, so shutdown waits for the title to be stored
+	ctx, done, err := s.Generations.Start()
+	if err != nil {
+		return
+	}
+	go func() {
+		defer done()
+		title, err := s.Titles.Generate(ctx, chat.ID, services.UsageOwner{Email: user.Email, Tenant: user.Tenant},
+			question.Content, reply.Content)
+		if err != nil {
+			log.Printf("Failed to generate title for chat %s: %v", chat.ID, err)
+			return
+		}
+		updated, err := s.Store.UpdateChatTitle(ctx, database.UpdateChatTitleParams{
+			ID:            chat.ID,
+			Title:         chatTitle(title),
+			PreviousTitle: chat.Title,
+			UpdatedAt:     time.Now().UTC(),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Renamed or deleted by the user in the meantime
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to store title of chat %s: %v", chat.ID, err)
+			return
+		}
+		s.Notifier.Publish(user.Email, chatUpdated(updated))
+	}()
+}
+
+// chatTitle derives the chat title from the first message
+func chatTitle(content string) string {
+	return truncate(content, maxTitleLength)
+}
+
+// truncate shortens the text to at most maxLength characters, marking cut text with an ellipsis
+func truncate(content string, maxLength int) string {
+	runes := []rune(content)
+	if len(runes) <= maxLength {
+		return content
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
+
+// chatCursor is the position of the last chat of a page in the sort order, clients get it as opaque string
+type chatCursor struct {
+	Sort   GetChatsParamsSort `json:"s"`
+	Pinned bool               `json:"p"`
+	Date   time.Time          `json:"d"`
+	ID     uuid.UUID          `json:"i"`
+}
+
+func encodeChatCursor(cursor chatCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeChatCursor(value string) (chatCursor, error) {
+	var cursor chatCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(decoded, &cursor)
+	return cursor, err
+}
+
+// chatSortColumn maps the sort parameter to the column GetChatsByUserEmail sorts by
+func chatSortColumn(sort GetChatsParamsSort) string {
+	if sort == CreatedAt {
+		return "created_at"
+	}
+	return "last_active_date"
 }
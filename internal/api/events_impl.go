@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// eventHeartbeat is the interval of the comments keeping idle event streams open through proxies.
+// Writing them is also how disconnected clients are noticed.
+const eventHeartbeat = 15 * time.Second
+
+// Types of the events pushed to clients
+const eventChatUpdated = "chat.updated"
+
+func (s *ChatServer) StreamEvents(c *fiber.Ctx) error {
+	user := currentUser(c)
+	events, unsubscribe := s.Notifier.Subscribe(user.Email)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		heartbeat := time.NewTicker(eventHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event.Data)
+				if err != nil {
+					log.Printf("Failed to encode %s event: %v", event.Type, err)
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// chatUpdated creates the event telling clients about a changed chat
+func chatUpdated(chat database.Chat) services.Event {
+	return services.Event{Type: eventChatUpdated, Data: toChatDTO(chat)}
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/opensearch"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// exportBatchSize is the number of messages loaded with their sources and usage at a time while streaming an export
+const exportBatchSize = 100
+
+// exportTimeFormat is how dates are written in Markdown and HTML transcripts
+const exportTimeFormat = "2006-01-02 15:04:05 UTC"
+
+// maxExportFilenameLength is the maximum length of the file name derived from the chat title, without extension
+const maxExportFilenameLength = 60
+
+func (s *ChatServer) ExportChat(c *fiber.Ctx, chatId uuid.UUID, params ExportChatParams) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+	format := Markdown
+	if params.Format != nil {
+		format = *params.Format
+	}
+	exporter := newChatExporter(format)
+	c.Attachment(exportFilename(chat.Title) + exporter.extension())
+	c.Set(fiber.HeaderContentType, exporter.contentType())
+	s.Events.Index(opensearch.ChatExported(user.Email, chat.ID, string(format)))
+
+	// Errors can't be reported once the response started, the client gets a truncated transcript
+	exportedAt := time.Now().UTC()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := s.writeExport(ctx, w, exporter, chat, exportedAt); err != nil {
+			log.Printf("Failed to export chat %s: %v", chat.ID, err)
+		}
+	})
+	return nil
+}
+
+// writeExport writes the transcript of the active conversation page by page, every page is sent to the client
+// before the next one is loaded after its last message
+func (s *ChatServer) writeExport(ctx context.Context, w *bufio.Writer, exporter chatExporter, chat database.Chat, exportedAt time.Time) error {
+	if err := exporter.begin(w, chat, exportedAt); err != nil {
+		return err
+	}
+	var after uuid.NullUUID
+	for {
+		page, err := s.Store.GetActivePathAfter(ctx, database.GetActivePathAfterParams{
+			ChatID:   chat.ID,
+			AfterID:  after,
+			PageSize: exportBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("fetch messages: %w", err)
+		}
+		// The system prompt is not part of the conversation the user sees
+		messages := slices.DeleteFunc(slices.Clone(page), func(message database.Message) bool {
+			return message.SenderType == senderTypeBackend
+		})
+		dtos, err := s.exportedMessageDTOs(ctx, messages)
+		if err != nil {
+			return err
+		}
+		for _, dto := range dtos {
+			if err := exporter.message(w, dto); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(page) < exportBatchSize {
+			break
+		}
+		after = uuid.NullUUID{UUID: page[len(page)-1].ID, Valid: true}
+	}
+	if err := exporter.end(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// exportedMessageDTOs maps messages to the API representation extended with the model and tokens of replies
+func (s *ChatServer) exportedMessageDTOs(ctx context.Context, messages []database.Message) ([]ExportedMessageDTO, error) {
+	dtos, err := s.messageDTOs(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	for _, message := range messages {
+		if message.SenderType == senderTypeLLM {
+			ids = append(ids, message.ID)
+		}
+	}
+	usage := make(map[uuid.UUID]MessageUsageDTO)
+	if len(ids) > 0 {
+		rows, err := s.Store.GetMessageTokenUsage(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("fetch token usage: %w", err)
+		}
+		for _, row := range rows {
+			usage[row.MessageID.UUID] = toMessageUsageDTO(row)
+		}
+	}
+
+	exported := make([]ExportedMessageDTO, 0, len(dtos))
+	for i, dto := range dtos {
+		exportedDTO := toExportedMessageDTO(dto)
+		if messageUsage, ok := usage[messages[i].ID]; ok {
+			exportedDTO.Usage = &messageUsage
+		}
+		exported = append(exported, exportedDTO)
+	}
+	return exported, nil
+}
+
+// chatExporter writes a transcript in one format: the chat metadata, every message and the end of the document
+type chatExporter interface {
+	contentType() string
+	extension() string
+	begin(w io.Writer, chat database.Chat, exportedAt time.Time) error
+	message(w io.Writer, message ExportedMessageDTO) error
+	end(w io.Writer) error
+}
+
+func newChatExporter(format ExportChatParamsFormat) chatExporter {
+	switch format {
+	case Json:
+		return &jsonExporter{}
+	case Html:
+		return &htmlExporter{}
+	default:
+		return &markdownExporter{}
+	}
+}
+
+// markdownExporter writes the transcript as Markdown, the messages keep their own Markdown formatting
+type markdownExporter struct{}
+
+func (e *markdownExporter) contentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (e *markdownExporter) extension() string {
+	return ".md"
+}
+
+func (e *markdownExporter) begin(w io.Writer, chat database.Chat, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(w, "# %s\n\n- Chat: %s\n- Created: %s\n- Last active: %s\n- Exported: %s\n\n---\n",
+		singleLine(chat.Title), chat.ID,
+		chat.CreatedAt.UTC().Format(exportTimeFormat),
+		chat.LastActiveDate.UTC().Format(exportTimeFormat),
+		exportedAt.Format(exportTimeFormat))
+	return err
+}
+
+func (e *markdownExporter) message(w io.Writer, message ExportedMessageDTO) error {
+	heading := exportSender(message) + " · " + message.CreatedAt.UTC().Format(exportTimeFormat)
+	if message.Usage != nil && message.Usage.Model != nil {
+		heading += " · " + *message.Usage.Model
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n### %s\n\n%s\n", heading, strings.TrimSpace(*message.Content))
+	if message.Incomplete != nil && *message.Incomplete {
+		b.WriteString("\n_The generation of this reply was interrupted._\n")
+	}
+	if message.Sources != nil {
+		b.WriteString("\nSources:\n\n")
+		for i, source := range *message.Sources {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, singleLine(*source.Title))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (e *markdownExporter) end(w io.Writer) error {
+	return nil
+}
+
+// jsonExporter writes the transcript as ChatExportDTO, one message at a time
+type jsonExporter struct {
+	messages int
+}
+
+func (e *jsonExporter) contentType() string {
+	return fiber.MIMEApplicationJSONCharsetUTF8
+}
+
+func (e *jsonExporter) extension() string {
+	return ".json"
+}
+
+func (e *jsonExporter) begin(w io.Writer, chat database.Chat, exportedAt time.Time) error {
+	exportedAtJSON, err := json.Marshal(exportedAt)
+	if err != nil {
+		return err
+	}
+	chatJSON, err := json.Marshal(toChatDTO(chat))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, `{"exportedAt":%s,"chat":%s,"messages":[`, exportedAtJSON, chatJSON)
+	return err
+}
+
+func (e *jsonExporter) message(w io.Writer, message ExportedMessageDTO) error {
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if e.messages > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	e.messages++
+	_, err = w.Write(messageJSON)
+	return err
+}
+
+func (e *jsonExporter) end(w io.Writer) error {
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// htmlExporter writes a self-contained HTML transcript with inline styles that prints well to PDF
+type htmlExporter struct{}
+
+// exportStyle is the stylesheet of HTML transcripts
+const exportStyle = `body{font-family:-apple-system,"Segoe UI",Roboto,Helvetica,Arial,sans-serif;color:#1f2328;max-width:48rem;margin:2rem auto;padding:0 1rem;line-height:1.5}
+h1{font-size:1.6rem;margin-bottom:.5rem}
+dl.meta{display:grid;grid-template-columns:max-content 1fr;gap:.2rem 1rem;color:#59636e;font-size:.9rem}
+dl.meta dd{margin:0}
+article{border:1px solid #d1d9e0;border-radius:6px;margin:1rem 0;padding:.75rem 1rem;break-inside:avoid-page}
+article.llm{background:#f6f8fa}
+article header{color:#59636e;font-size:.85rem;margin-bottom:.5rem}
+article header strong{color:#1f2328}
+.content{white-space:pre-wrap;overflow-wrap:anywhere}
+.note{font-style:italic;color:#9a6700}
+ol.sources{font-size:.85rem;color:#59636e}
+@page{margin:2cm}
+@media print{body{margin:0;max-width:none}article.llm{background:none}}`
+
+func (e *htmlExporter) contentType() string {
+	return fiber.MIMETextHTMLCharsetUTF8
+}
+
+func (e *htmlExporter) extension() string {
+	return ".html"
+}
+
+func (e *htmlExporter) begin(w io.Writer, chat database.Chat, exportedAt time.Time) error {
+	title := html.EscapeString(singleLine(chat.Title))
+	_, err := fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+%s
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<dl class="meta">
+<dt>Chat</dt><dd>%s</dd>
+<dt>Created</dt><dd>%s</dd>
+<dt>Last active</dt><dd>%s</dd>
+<dt>Exported</dt><dd>%s</dd>
+</dl>
+<main>
+`, title, exportStyle, title, chat.ID,
+		chat.CreatedAt.UTC().Format(exportTimeFormat),
+		chat.LastActiveDate.UTC().Format(exportTimeFormat),
+		exportedAt.Format(exportTimeFormat))
+	return err
+}
+
+func (e *htmlExporter) message(w io.Writer, message ExportedMessageDTO) error {
+	var b strings.Builder
+	createdAt := message.CreatedAt.UTC()
+	fmt.Fprintf(&b, "<article class=\"%s\">\n<header><strong>%s</strong> · <time datetime=\"%s\">%s</time>",
+		strings.ToLower(string(*message.SenderType)), exportSender(message),
+		createdAt.Format(time.RFC3339), createdAt.Format(exportTimeFormat))
+	if message.Usage != nil && message.Usage.Model != nil {
+		fmt.Fprintf(&b, " · %s", html.EscapeString(*message.Usage.Model))
+	}
+	fmt.Fprintf(&b, "</header>\n<div class=\"content\">%s</div>\n", html.EscapeString(strings.TrimSpace(*message.Content)))
+	if message.Incomplete != nil && *message.Incomplete {
+		b.WriteString("<p class=\"note\">The generation of this reply was interrupted.</p>\n")
+	}
+	if message.Sources != nil {
+		b.WriteString("<ol class=\"sources\">\n")
+		for _, source := range *message.Sources {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(*source.Title))
+		}
+		b.WriteString("</ol>\n")
+	}
+	b.WriteString("</article>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (e *htmlExporter) end(w io.Writer) error {
+	_, err := io.WriteString(w, "</main>\n</body>\n</html>\n")
+	return err
+}
+
+// exportSender names the sender of a message in transcripts
+func exportSender(message ExportedMessageDTO) string {
+	if *message.SenderType == LLM {
+		return "Assistant"
+	}
+	return "User"
+}
+
+// exportFilename derives a file name from the chat title, keeping ASCII letters and digits
+func exportFilename(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= maxExportFilenameLength {
+			break
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "chat"
+	}
+	return name
+}
+
+// singleLine joins the lines of a title so it fits in a heading or list item
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/importer"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *ChatServer) ImportChats(c *fiber.Ctx, params ImportChatsParams) error {
+	var format importer.Format
+	if params.Format != nil {
+		format = importer.Format(*params.Format)
+	}
+	archive, err := importer.Parse(c.Body(), format)
+	if err != nil {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "body", Value: err.Error()})
+	}
+
+	var userEmail string
+	if params.UserEmail != nil {
+		userEmail = string(*params.UserEmail)
+	}
+	results := s.Importer.Import(c.UserContext(), archive, userEmail)
+	return c.JSON(toImportReportDTO(archive.Format, results))
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/knowledge"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// maxExcerptLength is the maximum number of characters of a chunk stored as source excerpt
+const maxExcerptLength = 300
+
+// retrieveSources finds the knowledge base chunks relevant to the user message.
+// Answers without documentation are better than no answers, so failures are only logged.
+func (s *ChatServer) retrieveSources(ctx context.Context, message database.Message) []knowledge.Match {
+	if s.Knowledge == nil {
+		return nil
+	}
+	matches, err := s.Knowledge.Retrieve(ctx, message.Content)
+	if err != nil {
+		log.Printf("Failed to retrieve knowledge for message %s: %v", message.ID, err)
+		return nil
+	}
+	return matches
+}
+
+// storeSources stores the chunks passed to the LLM as sources of the reply
+func (s *ChatServer) storeSources(ctx context.Context, reply database.Message, sources []knowledge.Match) {
+	for i, source := range sources {
+		excerpt := []rune(source.Content)
+		if len(excerpt) > maxExcerptLength {
+			excerpt = append(excerpt[:maxExcerptLength-1], '…')
+		}
+		err := s.Store.CreateMessageSource(ctx, database.CreateMessageSourceParams{
+			MessageID:  reply.ID,
+			Position:   int32(i + 1),
+			DocumentID: uuid.NullUUID{UUID: source.DocumentID, Valid: true},
+			Title:      source.Title,
+			Excerpt:    string(excerpt),
+			Score:      source.Score,
+		})
+		if err != nil {
+			log.Printf("Failed to store sources of message %s: %v", reply.ID, err)
+			return
+		}
+	}
+}
+
+// attachSources adds the stored sources to the DTOs of the messages
+func (s *ChatServer) attachSources(ctx context.Context, messages []database.Message, dtos []MessageDTO) error {
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		if message.SenderType == senderTypeLLM {
+			ids = append(ids, message.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sources, err := s.Store.GetMessageSources(ctx, ids)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch message sources")
+	}
+	byMessage := make(map[uuid.UUID][]SourceDTO)
+	for _, source := range sources {
+		byMessage[source.MessageID] = append(byMessage[source.MessageID], toSourceDTO(source))
+	}
+	for i, message := range messages {
+		if messageSources, ok := byMessage[message.ID]; ok {
+			dtos[i].Sources = &messageSources
+		}
+	}
+	return nil
+}
+
+// maxListedJobs is the number of most recent ingestion jobs returned by GetIngestionJobs
+const maxListedJobs = 100
+
+// retryAfterQueueFull is the delay clients are asked to wait when the ingestion queue is full
+const retryAfterQueueFull = 30 * time.Second
+
+// errKnowledgeDisabled is returned by the admin endpoints if the knowledge base is disabled
+var errKnowledgeDisabled = fiber.NewError(fiber.StatusNotFound, "The knowledge base is not enabled")
+
+func (s *ChatServer) GetDocuments(c *fiber.Ctx) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	documents, err := s.Store.ListKnowledgeDocuments(c.UserContext())
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch documents")
+	}
+	return c.JSON(toDocumentDTOs(documents))
+}
+
+func (s *ChatServer) UploadDocument(c *fiber.Ctx) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "file", Value: "A document is required"})
+	}
+	format, err := knowledge.FormatFromFilename(file.Filename)
+	if err != nil {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "file", Value: "Unsupported document format, use .md, .html, .pdf or .txt"})
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read document")
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read document")
+	}
+
+	job, err := s.Knowledge.Upload(c.UserContext(), filepath.Base(file.Filename), format, data)
+	if err != nil {
+		return ingestionError(err)
+	}
+	return acceptedJob(c, job)
+}
+
+func (s *ChatServer) DeleteDocument(c *fiber.Ctx, documentId openapi_types.UUID) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	err := s.Knowledge.DeleteDocument(c.UserContext(), documentId)
+	if errors.Is(err, knowledge.ErrDocumentNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "The requested document could not be found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete document")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *ChatServer) ReindexDocument(c *fiber.Ctx, documentId openapi_types.UUID) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	job, err := s.Knowledge.Reindex(c.UserContext(), documentId)
+	if errors.Is(err, knowledge.ErrDocumentNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "The requested document could not be found")
+	}
+	if err != nil {
+		return ingestionError(err)
+	}
+	return acceptedJob(c, job)
+}
+
+func (s *ChatServer) GetIngestionJobs(c *fiber.Ctx) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	jobs, err := s.Store.ListKnowledgeJobs(c.UserContext(), maxListedJobs)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch ingestion jobs")
+	}
+	dtos := make([]IngestionJobDTO, 0, len(jobs))
+	for _, job := range jobs {
+		dtos = append(dtos, toIngestionJobDTO(job))
+	}
+	return c.JSON(dtos)
+}
+
+func (s *ChatServer) GetIngestionJob(c *fiber.Ctx, jobId openapi_types.UUID) error {
+	if s.Knowledge == nil {
+		return errKnowledgeDisabled
+	}
+	job, err := s.Store.GetKnowledgeJob(c.UserContext(), jobId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fiber.NewError(fiber.StatusNotFound, "The requested ingestion job could not be found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch ingestion job")
+	}
+	return c.JSON(toIngestionJobDTO(job))
+}
+
+// acceptedJob responds with the queued job and where to poll its status
+func acceptedJob(c *fiber.Ctx, job database.KbIngestionJob) error {
+	c.Location("/v1/admin/ingestion-jobs/" + job.ID.String())
+	return c.Status(fiber.StatusAccepted).JSON(toIngestionJobDTO(job))
+}
+
+// ingestionError maps the errors of queuing an ingestion job to API errors
+func ingestionError(err error) error {
+	if errors.Is(err, knowledge.ErrQueueFull) {
+		return apperrors.NewAppError(apperrors.UnavailableError, "The ingestion queue is full, please try again later").
+			WithRetryAfter(retryAfterQueueFull)
+	}
+	return apperrors.WrapAppError(apperrors.ServerError, "Failed to queue document for ingestion", err)
+}
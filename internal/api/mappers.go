@@ -0,0 +1,344 @@
+package api
+
+import (
+	"strings"
+
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/importer"
+	"ai-chat-service-go/internal/services"
+
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// CreateChatResponse is the response of CreateChat, a ChatDTO extended with the first message
+type CreateChatResponse struct {
+	ChatDTO
+	InitialMessage *MessageDTO `json:"initialMessage,omitempty"`
+}
+
+// toChatDTO maps the sqlc chat model to the API representation
+func toChatDTO(chat database.Chat) ChatDTO {
+	return ChatDTO{
+		Id:             &chat.ID,
+		Title:          &chat.Title,
+		LastActiveDate: &chat.LastActiveDate,
+		CreatedAt:      &chat.CreatedAt,
+		Pinned:         &chat.Pinned,
+		Archived:       &chat.Archived,
+	}
+}
+
+// toChatListDTOs maps a page of chats to the API representation including the message count and last message
+func toChatListDTOs(chats []database.GetChatsByUserEmailRow) []ChatDTO {
+	dtos := make([]ChatDTO, 0, len(chats))
+	for _, chat := range chats {
+		messageCount := int32(chat.MessageCount)
+		dto := ChatDTO{
+			Id:             &chat.ID,
+			Title:          &chat.Title,
+			LastActiveDate: &chat.LastActiveDate,
+			CreatedAt:      &chat.CreatedAt,
+			Pinned:         &chat.Pinned,
+			Archived:       &chat.Archived,
+			MessageCount:   &messageCount,
+		}
+		if chat.LastMessage.Valid {
+			preview := truncate(chat.LastMessage.String, maxPreviewLength)
+			dto.LastMessage = &preview
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos
+}
+
+// toMessageDTO maps the sqlc message model to the API representation
+func toMessageDTO(message database.Message) MessageDTO {
+	senderType := SenderType(strings.ToUpper(message.SenderType))
+	dto := MessageDTO{
+		Id:         &message.ID,
+		ChatId:     &message.ChatID,
+		Content:    &message.Content,
+		SenderType: &senderType,
+		CreatedAt:  &message.CreatedAt,
+		Incomplete: &message.Incomplete,
+	}
+	if message.ParentID.Valid {
+		dto.ParentId = &message.ParentID.UUID
+	}
+	return dto
+}
+
+// toMessageDTOs maps a list of sqlc message models to the API representation
+func toMessageDTOs(messages []database.Message) []MessageDTO {
+	dtos := make([]MessageDTO, 0, len(messages))
+	for _, message := range messages {
+		dtos = append(dtos, toMessageDTO(message))
+	}
+	return dtos
+}
+
+// toExportedMessageDTO extends a message with the fields only exports carry
+func toExportedMessageDTO(dto MessageDTO) ExportedMessageDTO {
+	return ExportedMessageDTO{
+		Id:           dto.Id,
+		ChatId:       dto.ChatId,
+		ParentId:     dto.ParentId,
+		Content:      dto.Content,
+		SenderType:   dto.SenderType,
+		CreatedAt:    dto.CreatedAt,
+		Incomplete:   dto.Incomplete,
+		Prompt:       dto.Prompt,
+		Variant:      dto.Variant,
+		VariantCount: dto.VariantCount,
+		Sources:      dto.Sources,
+	}
+}
+
+// toMessageUsageDTO maps the model and tokens of a reply to the API representation
+func toMessageUsageDTO(row database.GetMessageTokenUsageRow) MessageUsageDTO {
+	return MessageUsageDTO{
+		Model:            &row.Model,
+		PromptTokens:     &row.PromptTokens,
+		CompletionTokens: &row.CompletionTokens,
+	}
+}
+
+// toQuotaDTO maps the quota status to the API representation, omitting the values of unlimited quotas
+func toQuotaDTO(status services.QuotaStatus) *QuotaDTO {
+	dto := &QuotaDTO{
+		Limit:    &status.Limit,
+		Used:     &status.Used,
+		ResetsAt: &status.ResetsAt,
+	}
+	if status.Limit > 0 {
+		remaining := max(status.Limit-status.Used, 0)
+		dto.Remaining = &remaining
+	}
+	if status.TenantLimit > 0 {
+		dto.TenantLimit = &status.TenantLimit
+		dto.TenantUsed = &status.TenantUsed
+	}
+	return dto
+}
+
+// toDailyUsageDTO maps the tokens consumed on a day to the API representation
+func toDailyUsageDTO(row database.GetDailyTokenUsageRow) DailyUsageDTO {
+	total := row.PromptTokens + row.CompletionTokens
+	return DailyUsageDTO{
+		Date:             &openapi_types.Date{Time: row.Day},
+		PromptTokens:     &row.PromptTokens,
+		CompletionTokens: &row.CompletionTokens,
+		TotalTokens:      &total,
+	}
+}
+
+// toChatUsageDTO maps the tokens consumed in a chat to the API representation
+func toChatUsageDTO(row database.GetChatTokenUsageRow) ChatUsageDTO {
+	total := row.PromptTokens + row.CompletionTokens
+	return ChatUsageDTO{
+		ChatId:           &row.ChatID.UUID,
+		Title:            &row.Title,
+		PromptTokens:     &row.PromptTokens,
+		CompletionTokens: &row.CompletionTokens,
+		TotalTokens:      &total,
+	}
+}
+
+// toBranchDTO maps a branch ending in the leaf to the API representation
+func toBranchDTO(leaf database.Message, active bool, messageCount int, fork uuid.NullUUID) BranchDTO {
+	preview := chatTitle(leaf.Content)
+	dto := BranchDTO{
+		LeafId:         leaf.ID,
+		Active:         active,
+		MessageCount:   int32(messageCount),
+		Preview:        &preview,
+		LastActiveDate: leaf.CreatedAt,
+	}
+	if fork.Valid {
+		dto.ForkMessageId = &fork.UUID
+	}
+	return dto
+}
+
+// toPromptVersionDTO maps a prompt template version to the reference returned with messages
+func toPromptVersionDTO(template database.PromptTemplate) *PromptVersionDTO {
+	return &PromptVersionDTO{
+		Name:    &template.Name,
+		Version: &template.Version,
+	}
+}
+
+// toPromptTemplateDTOs maps a list of prompt template versions to the API representation
+func toPromptTemplateDTOs(templates []database.PromptTemplate) []PromptTemplateDTO {
+	dtos := make([]PromptTemplateDTO, 0, len(templates))
+	for _, template := range templates {
+		dtos = append(dtos, PromptTemplateDTO{
+			Name:      &template.Name,
+			Version:   &template.Version,
+			CreatedAt: &template.CreatedAt,
+		})
+	}
+	return dtos
+}
+
+// toSourceDTO maps a stored source of a reply to the API representation
+func toSourceDTO(source database.MessageSource) SourceDTO {
+	dto := SourceDTO{
+		Title:   &source.Title,
+		Excerpt: &source.Excerpt,
+		Score:   &source.Score,
+	}
+	if source.DocumentID.Valid {
+		dto.DocumentId = &source.DocumentID.UUID
+	}
+	return dto
+}
+
+// toDocumentDTOs maps the knowledge base documents to the API representation
+func toDocumentDTOs(documents []database.ListKnowledgeDocumentsRow) []DocumentDTO {
+	dtos := make([]DocumentDTO, 0, len(documents))
+	for _, document := range documents {
+		format := DocumentFormat(strings.ToUpper(document.Format))
+		status := IngestionStatus(strings.ToUpper(document.Status))
+		chunks := int32(document.Chunks)
+		dtos = append(dtos, DocumentDTO{
+			Id:        &document.ID,
+			Source:    &document.Source,
+			Title:     &document.Title,
+			Format:    &format,
+			Chunks:    &chunks,
+			Status:    &status,
+			CreatedAt: &document.CreatedAt,
+			UpdatedAt: &document.UpdatedAt,
+		})
+	}
+	return dtos
+}
+
+// toIngestionJobDTO maps an ingestion job to the API representation
+func toIngestionJobDTO(job database.KbIngestionJob) IngestionJobDTO {
+	kind := IngestionJobDTOKind(strings.ToUpper(job.Kind))
+	status := IngestionStatus(strings.ToUpper(job.Status))
+	dto := IngestionJobDTO{
+		Id:             &job.ID,
+		Source:         &job.Source,
+		Kind:           &kind,
+		Status:         &status,
+		Chunks:         &job.Chunks,
+		EmbeddedChunks: &job.EmbeddedChunks,
+		CreatedAt:      &job.CreatedAt,
+		UpdatedAt:      &job.UpdatedAt,
+	}
+	if job.DocumentID.Valid {
+		dto.DocumentId = &job.DocumentID.UUID
+	}
+	if job.Error != "" {
+		dto.Error = &job.Error
+	}
+	return dto
+}
+
+// toImportReportDTO maps the results of an import to the API representation
+func toImportReportDTO(format importer.Format, results []importer.Result) ImportReportDTO {
+	report := ImportReportDTO{
+		Format: ImportReportDTOFormat(format),
+		Chats:  make([]ImportResultDTO, 0, len(results)),
+	}
+	for _, result := range results {
+		dto := ImportResultDTO{Status: ImportStatus(strings.ToUpper(string(result.Status)))}
+		switch result.Status {
+		case importer.StatusImported:
+			report.Imported++
+		case importer.StatusSkipped:
+			report.Skipped++
+		case importer.StatusFailed:
+			report.Failed++
+		}
+		if result.ExternalID != "" {
+			dto.ExternalId = &result.ExternalID
+		}
+		if result.Title != "" {
+			dto.Title = &result.Title
+		}
+		if result.UserEmail != "" {
+			dto.UserEmail = &result.UserEmail
+		}
+		if result.ChatID != uuid.Nil {
+			dto.ChatId = &result.ChatID
+		}
+		if result.Messages > 0 {
+			messageCount := int32(result.Messages)
+			dto.MessageCount = &messageCount
+		}
+		if result.Error != "" {
+			dto.Error = &result.Error
+		}
+		report.Chats = append(report.Chats, dto)
+	}
+	return report
+}
+
+// toSearchHitDTO maps a search hit to the API representation, hits on chat titles have no message
+func toSearchHitDTO(hit database.SearchChatsRow) SearchHitDTO {
+	snippet := highlight(hit.Snippet)
+	dto := SearchHitDTO{
+		ChatId:    &hit.ChatID,
+		ChatTitle: &hit.ChatTitle,
+		Snippet:   &snippet,
+		Rank:      &hit.Rank,
+		CreatedAt: &hit.CreatedAt,
+	}
+	if hit.MessageID.Valid {
+		dto.MessageId = &hit.MessageID.UUID
+	}
+	if hit.SenderType.Valid {
+		senderType := SenderType(strings.ToUpper(hit.SenderType.String))
+		dto.SenderType = &senderType
+	}
+	return dto
+}
+
+// toDataErasureDTO maps an erasure record to the API representation
+func toDataErasureDTO(erasure database.DataErasure) DataErasureDTO {
+	return DataErasureDTO{
+		Id:          erasure.ID,
+		SubjectHash: erasure.SubjectHash,
+		InitiatedBy: DataErasureDTOInitiatedBy(erasure.InitiatedBy),
+		Chats:       erasure.Chats,
+		Messages:    erasure.Messages,
+		TokenUsage:  erasure.TokenUsage,
+		ErasedAt:    erasure.ErasedAt,
+	}
+}
+
+// toPurgeReportDTO maps the purge totals and the latest purge runs to the API representation
+func toPurgeReportDTO(totals database.GetPurgeTotalsRow, runs []database.PurgeRun) PurgeReportDTO {
+	report := PurgeReportDTO{
+		Totals: PurgeTotalsDTO{
+			Runs:            totals.Runs,
+			DeletedChats:    totals.DeletedChats,
+			InactiveChats:   totals.InactiveChats,
+			Messages:        totals.Messages,
+			ExpiredMessages: totals.ExpiredMessages,
+		},
+		Runs: make([]PurgeRunDTO, 0, len(runs)),
+	}
+	for _, run := range runs {
+		dto := PurgeRunDTO{
+			Id:              run.ID,
+			DryRun:          run.DryRun,
+			DeletedChats:    run.DeletedChats,
+			InactiveChats:   run.InactiveChats,
+			Messages:        run.Messages,
+			ExpiredMessages: run.ExpiredMessages,
+			StartedAt:       run.StartedAt,
+			FinishedAt:      run.FinishedAt,
+		}
+		if run.Error.Valid {
+			dto.Error = &run.Error.String
+		}
+		report.Runs = append(report.Runs, dto)
+	}
+	return report
+}
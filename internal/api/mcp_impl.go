@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/mcp"
+	"ai-chat-service-go/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// errMCPDisabled is returned by the MCP endpoint if the MCP server is disabled
+var errMCPDisabled = fiber.NewError(fiber.StatusNotFound, "The MCP server is not enabled")
+
+// defaultMCPChatLimit is the number of chats list_chats returns if the client doesn't ask for a limit
+const defaultMCPChatLimit = 20
+
+// MessageRateLimitScope is the rate limit bucket shared by the REST message routes and send_message
+const MessageRateLimitScope = "messages"
+
+func (s *ChatServer) HandleMcpMessage(c *fiber.Ctx) error {
+	if s.MCP == nil {
+		return errMCPDisabled
+	}
+	response, ok := s.MCP.Handle(c.UserContext(), c.Body(), s.mcpTools(currentUser(c)))
+	if response == nil {
+		return c.SendStatus(fiber.StatusAccepted)
+	}
+	if !ok {
+		c.Status(fiber.StatusBadRequest)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(response)
+}
+
+// OpenMcpStream tells clients that the server doesn't push messages, they only use POST
+func (s *ChatServer) OpenMcpStream(c *fiber.Ctx) error {
+	if s.MCP == nil {
+		return errMCPDisabled
+	}
+	c.Set(fiber.HeaderAllow, fiber.MethodPost)
+	return c.SendStatus(fiber.StatusMethodNotAllowed)
+}
+
+// mcpTools returns the tools of the MCP server bound to the user, they act on the user's chats like the REST API
+func (s *ChatServer) mcpTools(user *middleware.UserInfo) []mcp.ServerTool {
+	return []mcp.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "list_chats",
+				Description: "Lists the chats of the user that are not archived, pinned and then most recently active first, with id, title, last activity, message count and the beginning of the last message.",
+				InputSchema: json.RawMessage(`{
+					"type": "object",
+					"properties": {
+						"limit": {"type": "integer", "minimum": 1, "maximum": 100, "description": "Maximum number of chats, 20 by default"}
+					}
+				}`),
+			},
+			Handler: func(ctx context.Context, arguments json.RawMessage) (string, error) {
+				var args struct {
+					Limit int `json:"limit"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+				if args.Limit <= 0 {
+					args.Limit = defaultMCPChatLimit
+				}
+				chats, err := s.Store.GetChatsByUserEmail(ctx, database.GetChatsByUserEmailParams{
+					UserEmail: user.Email,
+					Archived:  sql.NullBool{Bool: false, Valid: true},
+					SortBy:    chatSortColumn(LastActiveDate),
+					PageLimit: int32(min(args.Limit, maxChatPageSize)),
+				})
+				if err != nil {
+					return "", errors.New("failed to fetch chats")
+				}
+				return mcpOutput(toChatListDTOs(chats))
+			},
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "get_chat_messages",
+				Description: "Returns the messages of the active branch of a chat of the user in order, with sender type (USER, LLM or BACKEND), content and time.",
+				InputSchema: json.RawMessage(`{
+					"type": "object",
+					"properties": {
+						"chatId": {"type": "string", "format": "uuid", "description": "Id of the chat"}
+					},
+					"required": ["chatId"]
+				}`),
+			},
+			Handler: func(ctx context.Context, arguments json.RawMessage) (string, error) {
+				var args struct {
+					ChatID string `json:"chatId"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+				chatID, err := uuid.Parse(args.ChatID)
+				if err != nil {
+					return "", errors.New("chatId is not a valid UUID")
+				}
+				chat, err := s.getOwnedChat(ctx, user, chatID)
+				if err != nil {
+					return "", err
+				}
+				tree, err := s.messageTree(ctx, chat)
+				if err != nil {
+					return "", err
+				}
+				dtos, err := s.messageDTOs(ctx, tree.activePath())
+				if err != nil {
+					return "", err
+				}
+				return mcpOutput(dtos)
+			},
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "send_message",
+				Description: "Sends a message to a chat of the user and returns the reply of the assistant.",
+				InputSchema: json.RawMessage(`{
+					"type": "object",
+					"properties": {
+						"chatId": {"type": "string", "format": "uuid", "description": "Id of the chat"},
+						"content": {"type": "string", "minLength": 1, "description": "Text of the message"}
+					},
+					"required": ["chatId", "content"]
+				}`),
+			},
+			Handler: func(ctx context.Context, arguments json.RawMessage) (string, error) {
+				var args struct {
+					ChatID  string `json:"chatId"`
+					Content string `json:"content"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+				chatID, err := uuid.Parse(args.ChatID)
+				if err != nil {
+					return "", errors.New("chatId is not a valid UUID")
+				}
+				if strings.TrimSpace(args.Content) == "" {
+					return "", errors.New("content must not be empty")
+				}
+				if s.MaxContentLength > 0 && len([]rune(args.Content)) > s.MaxContentLength {
+					return "", fmt.Errorf("content must not be longer than %d characters", s.MaxContentLength)
+				}
+				if err := s.takeMessageRate(ctx, user); err != nil {
+					return "", err
+				}
+				chat, err := s.getOwnedChat(ctx, user, chatID)
+				if err != nil {
+					return "", err
+				}
+				reply, err := s.sendMessage(ctx, user, chat, args.Content)
+				if err != nil {
+					return "", err
+				}
+				dtos, err := s.messageDTOs(ctx, []database.Message{reply})
+				if err != nil {
+					return "", err
+				}
+				return mcpOutput(dtos[0])
+			},
+		},
+	}
+}
+
+// takeMessageRate applies the message rate limit of the REST routes to a message sent through MCP
+func (s *ChatServer) takeMessageRate(ctx context.Context, user *middleware.UserInfo) error {
+	if s.RateLimits == nil {
+		return nil
+	}
+	result, err := s.RateLimits.Take(ctx, middleware.UserRateLimitKey(MessageRateLimitScope, user), s.MessageRate)
+	if err != nil {
+		// Fail open like the middleware
+		log.Printf("Rate limiting failed: %v", err)
+		return nil
+	}
+	if !result.Allowed {
+		return fmt.Errorf("too many messages, please try again in %d seconds", int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+	return nil
+}
+
+// mcpOutput encodes a tool result as JSON text content
+func mcpOutput(value any) (string, error) {
+	output, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
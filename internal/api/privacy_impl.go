@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bufio"
+	"log"
+	"time"
+
+	"ai-chat-service-go/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func (s *ChatServer) ExportMyData(c *fiber.Ctx) error {
+	return s.exportData(c, currentUser(c).Email)
+}
+
+func (s *ChatServer) EraseMyData(c *fiber.Ctx) error {
+	return s.eraseData(c, currentUser(c).Email, services.ErasureBySelf)
+}
+
+func (s *ChatServer) ExportUserData(c *fiber.Ctx, userEmail openapi_types.Email) error {
+	return s.exportData(c, string(userEmail))
+}
+
+func (s *ChatServer) EraseUserData(c *fiber.Ctx, userEmail openapi_types.Email) error {
+	return s.eraseData(c, string(userEmail), services.ErasureByAdmin)
+}
+
+// exportData streams the ZIP archive of the data of the user. Errors can't be reported once the
+// response started, the client gets a truncated archive.
+func (s *ChatServer) exportData(c *fiber.Ctx, email string) error {
+	ctx := c.UserContext()
+	c.Attachment("personal-data-" + time.Now().UTC().Format(time.DateOnly) + ".zip")
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := s.DataSubjects.Export(ctx, w, email); err != nil {
+			log.Printf("Failed to export personal data: %v", err)
+			return
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func (s *ChatServer) eraseData(c *fiber.Ctx, email string, initiatedBy string) error {
+	erasure, err := s.DataSubjects.Erase(c.UserContext(), email, initiatedBy)
+	if err != nil {
+		log.Printf("Failed to erase personal data: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to erase data")
+	}
+	log.Printf("Erased personal data, erasure %s: %d chats, %d messages, %d token usage records anonymized",
+		erasure.ID, erasure.Chats, erasure.Messages, erasure.TokenUsage)
+	return c.JSON(toDataErasureDTO(erasure))
+}
+
+// defaultListedPurgeRuns is the number of purge runs returned unless a limit is given
+const defaultListedPurgeRuns = 20
+
+func (s *ChatServer) GetPurgeRuns(c *fiber.Ctx, params GetPurgeRunsParams) error {
+	ctx := c.UserContext()
+	limit := int32(defaultListedPurgeRuns)
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	totals, err := s.Store.GetPurgeTotals(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch purge runs")
+	}
+	runs, err := s.Store.ListPurgeRuns(ctx, limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch purge runs")
+	}
+	return c.JSON(toPurgeReportDTO(totals, runs))
+}
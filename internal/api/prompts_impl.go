@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/middleware"
+	"ai-chat-service-go/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (s *ChatServer) GetPromptTemplates(c *fiber.Ctx) error {
+	templates, err := s.Prompts.List(c.UserContext())
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch prompt templates")
+	}
+	return c.JSON(toPromptTemplateDTOs(templates))
+}
+
+// renderSystemPrompt renders the system prompt selected for a new chat.
+// Without a selection the default template is used, if the deployment has one.
+func (s *ChatServer) renderSystemPrompt(c *fiber.Ctx, user *middleware.UserInfo, body CreateChatJSONRequestBody) (*services.RenderedPrompt, error) {
+	name := s.Prompts.DefaultName()
+	if body.PromptTemplate != nil {
+		name = *body.PromptTemplate
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	locale := s.Prompts.DefaultLocale()
+	if body.Locale != nil {
+		locale = *body.Locale
+	} else if accepted := preferredLanguage(c.Get(fiber.HeaderAcceptLanguage)); accepted != "" {
+		locale = accepted
+	}
+
+	vars := services.NewPromptVariables(user.Name, user.Email, locale, user.Tenant)
+	prompt, err := s.Prompts.Render(c.UserContext(), name, vars)
+	if errors.Is(err, services.ErrPromptNotFound) {
+		if body.PromptTemplate == nil {
+			return nil, nil
+		}
+		return nil, apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "promptTemplate", Value: "Unknown prompt template"})
+	}
+	if err != nil {
+		return nil, apperrors.WrapAppError(apperrors.ServerError, "Failed to render system prompt", err)
+	}
+	return &prompt, nil
+}
+
+// promptTemplateID returns the template version of the system prompt at the start of the chat
+func promptTemplateID(history []database.Message) uuid.NullUUID {
+	for _, message := range history {
+		if message.SenderType != senderTypeBackend {
+			break
+		}
+		if message.PromptTemplateID.Valid {
+			return message.PromptTemplateID
+		}
+	}
+	return uuid.NullUUID{}
+}
+
+// preferredLanguage returns the first language of an Accept-Language header, e.g. "de-DE" for "de-DE,de;q=0.9"
+func preferredLanguage(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	language, _, _ := strings.Cut(first, ";")
+	language = strings.TrimSpace(language)
+	if language == "*" {
+		return ""
+	}
+	return language
+}
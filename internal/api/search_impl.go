@@ -0,0 +1,73 @@
+package api
+
+import (
+	"html"
+	"strings"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+func (s *ChatServer) SearchChats(c *fiber.Ctx, params SearchChatsParams) error {
+	user := currentUser(c)
+
+	query := strings.TrimSpace(params.Q)
+	if query == "" {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "q", Value: "The search query must not be empty"})
+	}
+	page, pageSize := int32(1), int32(defaultSearchPageSize)
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.PageSize != nil {
+		pageSize = *params.PageSize
+	}
+	if page < 1 {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "page", Value: "page must be at least 1"})
+	}
+	if pageSize < 1 || pageSize > maxSearchPageSize {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "pageSize", Value: "pageSize must be between 1 and 100"})
+	}
+
+	// One extra hit tells whether there is a next page
+	hits, err := s.Store.SearchChats(c.UserContext(), database.SearchChatsParams{
+		Query:      query,
+		UserEmail:  user.Email,
+		PageLimit:  pageSize + 1,
+		PageOffset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to search chats")
+	}
+	hasMore := len(hits) > int(pageSize)
+	if hasMore {
+		hits = hits[:pageSize]
+	}
+
+	dtos := make([]SearchHitDTO, 0, len(hits))
+	for _, hit := range hits {
+		dtos = append(dtos, toSearchHitDTO(hit))
+	}
+	return c.JSON(SearchResultDTO{
+		Hits:     &dtos,
+		Page:     &page,
+		PageSize: &pageSize,
+		HasMore:  &hasMore,
+	})
+}
+
+// highlight escapes the snippet for HTML, only the <mark> tags of the matched words are kept
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>").Replace(escaped)
+}
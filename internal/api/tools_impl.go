@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/middleware"
+	"ai-chat-service-go/internal/opensearch"
+	"ai-chat-service-go/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// complete generates the answer, letting the LLM call tools if any are registered.
+// Tool calls are stored with storeCtx below the question, so they are kept even if the generation is interrupted.
+// The ids of the stored records are returned to attach them to the reply.
+func (s *ChatServer) complete(ctx, storeCtx context.Context, user *middleware.UserInfo, chat database.Chat, question database.Message, req services.CompletionRequest) (services.Completion, []uuid.UUID, error) {
+	if s.Tools == nil {
+		completion, err := s.LLM.Complete(ctx, req)
+		return completion, nil, err
+	}
+	var records []uuid.UUID
+	toolUser := services.ToolUser{Email: user.Email, Tenant: user.Tenant, Roles: user.Roles}
+	completion, err := s.Tools.Complete(ctx, s.LLM, toolUser, req, func(result services.ToolResult) {
+		if id, ok := s.storeToolCall(storeCtx, user, chat, question, result); ok {
+			records = append(records, id)
+		}
+	})
+	return completion, records, err
+}
+
+// storeToolCall records a tool call and its result as backend message for auditability.
+// Losing the record must not fail the answer, so errors are only logged.
+func (s *ChatServer) storeToolCall(ctx context.Context, user *middleware.UserInfo, chat database.Chat, question database.Message, result services.ToolResult) (uuid.UUID, bool) {
+	content := fmt.Sprintf("Tool %s called with %s\n", result.Call.Function.Name, result.Call.Function.Arguments)
+	if result.Err != nil {
+		content += "Error: " + result.Err.Error()
+	} else {
+		content += "Result: " + result.Output
+	}
+
+	now := time.Now().UTC()
+	message, err := s.Store.CreateMessage(ctx, database.CreateMessageParams{
+		ID:         uuid.New(),
+		Content:    content,
+		SenderType: senderTypeBackend,
+		ChatID:     chat.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ParentID:   uuid.NullUUID{UUID: question.ID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to store call of tool %s in chat %s: %v", result.Call.Function.Name, chat.ID, err)
+		return uuid.Nil, false
+	}
+	s.Events.Index(opensearch.ToolCalled(user.Email, chat.ID, message.ID, result.Call.Function.Name))
+	return message.ID, true
+}
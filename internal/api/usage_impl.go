@@ -0,0 +1,81 @@
+package api
+
+import (
+	"time"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func (s *ChatServer) GetUsage(c *fiber.Ctx, params GetUsageParams) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	// The range defaults to the current month, both days are inclusive
+	now := time.Now().UTC()
+	from := services.MonthStart(now)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if params.From != nil {
+		from = params.From.Time
+	}
+	if params.To != nil {
+		to = params.To.Time
+	}
+	if from.After(to) {
+		return apperrors.NewAppError(apperrors.ValidationError, "The request contains invalid parameters",
+			apperrors.ErrorDetail{Field: "from", Value: "from must not be after to"})
+	}
+	rangeEnd := to.AddDate(0, 0, 1)
+
+	daily, err := s.Store.GetDailyTokenUsage(ctx, database.GetDailyTokenUsageParams{
+		UserEmail: user.Email,
+		FromTime:  from,
+		ToTime:    rangeEnd,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch usage")
+	}
+
+	chats, err := s.Store.GetChatTokenUsage(ctx, database.GetChatTokenUsageParams{
+		UserEmail: user.Email,
+		FromTime:  from,
+		ToTime:    rangeEnd,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch usage")
+	}
+
+	status, err := s.Quotas.Status(ctx, user.Email, user.Tenant, user.Roles)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch quota")
+	}
+
+	usage := UsageDTO{
+		From:  &openapi_types.Date{Time: from},
+		To:    &openapi_types.Date{Time: to},
+		Quota: toQuotaDTO(status),
+	}
+	var promptTokens, completionTokens int64
+	dailyDTOs := make([]DailyUsageDTO, 0, len(daily))
+	for _, day := range daily {
+		promptTokens += day.PromptTokens
+		completionTokens += day.CompletionTokens
+		dailyDTOs = append(dailyDTOs, toDailyUsageDTO(day))
+	}
+	chatDTOs := make([]ChatUsageDTO, 0, len(chats))
+	for _, chat := range chats {
+		chatDTOs = append(chatDTOs, toChatUsageDTO(chat))
+	}
+	totalTokens := promptTokens + completionTokens
+	usage.PromptTokens = &promptTokens
+	usage.CompletionTokens = &completionTokens
+	usage.TotalTokens = &totalTokens
+	usage.Daily = &dailyDTOs
+	usage.Chats = &chatDTOs
+
+	return c.JSON(usage)
+}
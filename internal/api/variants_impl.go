@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+
+	"ai-chat-service-go/internal/database"
+	apperrors "ai-chat-service-go/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (s *ChatServer) RegenerateMessage(c *fiber.Ctx, chatId uuid.UUID, messageId uuid.UUID) error {
+	user := currentUser(c)
+	ctx := c.UserContext()
+
+	chat, err := s.getOwnedChat(ctx, user, chatId)
+	if err != nil {
+		return err
+	}
+	message, err := s.getChatMessage(ctx, chat, messageId)
+	if err != nil {
+		return err
+	}
+	if message.SenderType != senderTypeLLM || !message.ParentID.Valid {
+		return apperrors.NewAppError(apperrors.ConflictError, "Only LLM replies can be regenerated")
+	}
+	question, err := s.getChatMessage(ctx, chat, message.ParentID.UUID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkQuota(ctx, user); err != nil {
+		return err
+	}
+	// The new variant is a sibling of the reply, so the conversation continues from it
+	reply, err := s.generateReply(ctx, user, chat, question)
+	if err != nil {
+		return err
+	}
+
+	dtos, err := s.messageDTOs(ctx, []database.Message{reply})
+	if err != nil {
+		return err
+	}
+	return c.JSON(dtos[0])
+}
+
+// variantKey identifies the siblings a message is a variant of
+type variantKey struct {
+	parentID   uuid.UUID
+	senderType string
+}
+
+// attachVariants adds the variant number and the number of variants to the DTOs of user messages and LLM replies
+func (s *ChatServer) attachVariants(ctx context.Context, messages []database.Message, dtos []MessageDTO) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// The messages belong to one chat, so all counts are fetched at once
+	counts, err := s.Store.CountVariantsByChatID(ctx, messages[0].ChatID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch message variants")
+	}
+	variants := make(map[variantKey]int32, len(counts))
+	for _, count := range counts {
+		variants[variantKey{count.ParentID.UUID, count.SenderType}] = int32(count.Variants)
+	}
+	for i, message := range messages {
+		if message.SenderType == senderTypeBackend {
+			continue
+		}
+		variant, variantCount := message.Variant, max(variants[variantKey{message.ParentID.UUID, message.SenderType}], 1)
+		dtos[i].Variant = &variant
+		dtos[i].VariantCount = &variantCount
+	}
+	return nil
+}
@@ -7,15 +7,39 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countDeletedChats = `-- name: CountDeletedChats :one
+SELECT COUNT(DISTINCT c.id) AS chats, COUNT(m.id) AS messages
+FROM chats c
+LEFT JOIN messages m ON m.chat_id = c.id
+WHERE c.deleted_at < $1
+`
+
+type CountDeletedChatsRow struct {
+	Chats    int64
+	Messages int64
+}
+
+// The chats and messages PurgeDeletedChats would remove, for dry runs
+func (q *Queries) CountDeletedChats(ctx context.Context, deletedBefore sql.NullTime) (CountDeletedChatsRow, error) {
+	row := q.db.QueryRowContext(ctx, countDeletedChats, deletedBefore)
+	var i CountDeletedChatsRow
+	err := row.Scan(
+		&i.Chats,
+		&i.Messages,
+	)
+	return i, err
+}
+
 const createChat = `-- name: CreateChat :one
-INSERT INTO chats (id, title, user_email, last_active_date, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, title, user_email, last_active_date, created_at, updated_at
+INSERT INTO chats (id, title, user_email, last_active_date, created_at, updated_at, tenant)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, title, user_email, last_active_date, created_at, updated_at, title_tsv, pinned, archived, deleted_at, tenant
 `
 
 type CreateChatParams struct {
@@ -25,6 +49,7 @@ type CreateChatParams struct {
 	LastActiveDate time.Time
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	Tenant         string
 }
 
 func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, error) {
@@ -35,6 +60,7 @@ func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, e
 		arg.LastActiveDate,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		arg.Tenant,
 	)
 	var i Chat
 	err := row.Scan(
@@ -44,15 +70,37 @@ func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, e
 		&i.LastActiveDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TitleTsv,
+		&i.Pinned,
+		&i.Archived,
+		&i.DeletedAt,
+		&i.Tenant,
 	)
 	return i, err
 }
 
+const deleteChat = `-- name: DeleteChat :exec
+UPDATE chats
+SET deleted_at = $2, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type DeleteChatParams struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) DeleteChat(ctx context.Context, arg DeleteChatParams) error {
+	_, err := q.db.ExecContext(ctx, deleteChat, arg.ID, arg.DeletedAt)
+	return err
+}
+
 const getChat = `-- name: GetChat :one
-SELECT id, title, user_email, last_active_date, created_at, updated_at FROM chats
-WHERE id = $1 LIMIT 1
+SELECT id, title, user_email, last_active_date, created_at, updated_at, title_tsv, pinned, archived, deleted_at, tenant FROM chats
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
+// Deleted chats are gone for the user, they only wait for the purge
 func (q *Queries) GetChat(ctx context.Context, id uuid.UUID) (Chat, error) {
 	row := q.db.QueryRowContext(ctx, getChat, id)
 	var i Chat
@@ -63,25 +111,111 @@ func (q *Queries) GetChat(ctx context.Context, id uuid.UUID) (Chat, error) {
 		&i.LastActiveDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TitleTsv,
+		&i.Pinned,
+		&i.Archived,
+		&i.DeletedAt,
+		&i.Tenant,
 	)
 	return i, err
 }
 
 const getChatsByUserEmail = `-- name: GetChatsByUserEmail :many
-SELECT id, title, user_email, last_active_date, created_at, updated_at FROM chats
-WHERE user_email = $1
-ORDER BY last_active_date DESC
+SELECT c.id, c.title, c.user_email, c.last_active_date, c.created_at, c.updated_at, c.title_tsv, c.pinned, c.archived, c.deleted_at, c.tenant,
+    conversation.message_count,
+    conversation.last_message
+FROM chats c
+LEFT JOIN LATERAL (
+    -- The active conversation follows the active continuation of every message, the latest one if none is active,
+    -- like the message tree of the API. Tool call records hang off replies and are not continuations.
+    WITH RECURSIVE active_path AS (
+        (SELECT m.id, m.sender_type, m.content, m.created_at FROM messages m
+        WHERE m.chat_id = c.id AND m.parent_id IS NULL
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1)
+        UNION ALL
+        SELECT next.id, next.sender_type, next.content, next.created_at FROM active_path p
+        CROSS JOIN LATERAL (
+            SELECT m.id, m.sender_type, m.content, m.created_at FROM messages m
+            WHERE m.parent_id = p.id AND m.sender_type <> 'backend'
+            ORDER BY m.active DESC, m.created_at DESC
+            LIMIT 1
+        ) next
+    )
+    SELECT
+        COUNT(*) FILTER (WHERE sender_type <> 'backend') AS message_count,
+        (SELECT left(content, 200) FROM active_path
+            WHERE sender_type <> 'backend'
+            ORDER BY created_at DESC
+            LIMIT 1) AS last_message
+    FROM active_path
+) conversation ON TRUE
+WHERE c.user_email = $1 AND c.deleted_at IS NULL
+    AND ($2::boolean IS NULL OR c.archived = $2)
+    AND ($3::boolean IS NULL OR c.pinned = $3)
+    AND ($4::text IS NULL OR strpos(lower(c.title), lower($4)) > 0)
+    AND ($5::timestamptz IS NULL OR CASE WHEN $6::text = 'created_at' THEN c.created_at ELSE c.last_active_date END >= $5)
+    AND ($7::timestamptz IS NULL OR CASE WHEN $6::text = 'created_at' THEN c.created_at ELSE c.last_active_date END < $7)
+    AND ($8::uuid IS NULL
+        OR (c.pinned, CASE WHEN $6::text = 'created_at' THEN c.created_at ELSE c.last_active_date END, c.id) < ($9::boolean, $10::timestamptz, $8))
+ORDER BY c.pinned DESC, CASE WHEN $6::text = 'created_at' THEN c.created_at ELSE c.last_active_date END DESC, c.id DESC
+LIMIT $11
 `
 
-func (q *Queries) GetChatsByUserEmail(ctx context.Context, userEmail string) ([]Chat, error) {
-	rows, err := q.db.QueryContext(ctx, getChatsByUserEmail, userEmail)
+type GetChatsByUserEmailParams struct {
+	UserEmail    string
+	Archived     sql.NullBool
+	Pinned       sql.NullBool
+	Title        sql.NullString
+	DateFrom     sql.NullTime
+	SortBy       string
+	DateTo       sql.NullTime
+	CursorID     uuid.NullUUID
+	CursorPinned sql.NullBool
+	CursorDate   sql.NullTime
+	PageLimit    int32
+}
+
+type GetChatsByUserEmailRow struct {
+	ID             uuid.UUID
+	Title          string
+	UserEmail      string
+	LastActiveDate time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	TitleTsv       interface{}
+	Pinned         bool
+	Archived       bool
+	DeletedAt      sql.NullTime
+	Tenant         string
+	MessageCount   int64
+	LastMessage    sql.NullString
+}
+
+// A page of the user's chats, pinned chats first and then newest first by the sort date (last_active_date or created_at).
+// The cursor is the position of the last chat of the previous page, filters that are not set match all chats.
+// The message count and last message only cover the active conversation, not discarded variants and branches.
+func (q *Queries) GetChatsByUserEmail(ctx context.Context, arg GetChatsByUserEmailParams) ([]GetChatsByUserEmailRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChatsByUserEmail,
+		arg.UserEmail,
+		arg.Archived,
+		arg.Pinned,
+		arg.Title,
+		arg.DateFrom,
+		arg.SortBy,
+		arg.DateTo,
+		arg.CursorID,
+		arg.CursorPinned,
+		arg.CursorDate,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Chat
+	var items []GetChatsByUserEmailRow
 	for rows.Next() {
-		var i Chat
+		var i GetChatsByUserEmailRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
@@ -89,6 +223,13 @@ func (q *Queries) GetChatsByUserEmail(ctx context.Context, userEmail string) ([]
 			&i.LastActiveDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TitleTsv,
+			&i.Pinned,
+			&i.Archived,
+			&i.DeletedAt,
+			&i.Tenant,
+			&i.MessageCount,
+			&i.LastMessage,
 		); err != nil {
 			return nil, err
 		}
@@ -103,6 +244,101 @@ func (q *Queries) GetChatsByUserEmail(ctx context.Context, userEmail string) ([]
 	return items, nil
 }
 
+const lockChat = `-- name: LockChat :exec
+SELECT id FROM chats
+WHERE id = $1
+FOR NO KEY UPDATE
+`
+
+// Serializes adding messages to the chat until the transaction ends, so siblings get distinct variant numbers. Inserts
+// that only reference the chat are not blocked.
+func (q *Queries) LockChat(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockChat, id)
+	return err
+}
+
+const purgeDeletedChats = `-- name: PurgeDeletedChats :one
+WITH batch AS (
+    SELECT id FROM chats
+    WHERE deleted_at < $1
+    ORDER BY deleted_at
+    LIMIT $2
+), purged AS (
+    DELETE FROM chats
+    WHERE id IN (SELECT id FROM batch)
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM purged) AS chats,
+    (SELECT COUNT(*) FROM messages WHERE chat_id IN (SELECT id FROM batch)) AS messages
+`
+
+type PurgeDeletedChatsParams struct {
+	DeletedBefore sql.NullTime
+	BatchSize     int32
+}
+
+type PurgeDeletedChatsRow struct {
+	Chats    int64
+	Messages int64
+}
+
+// Removes a batch of chats deleted before the cutoff, their messages and summaries are removed with them.
+// Returns the number of chats and messages removed.
+func (q *Queries) PurgeDeletedChats(ctx context.Context, arg PurgeDeletedChatsParams) (PurgeDeletedChatsRow, error) {
+	row := q.db.QueryRowContext(ctx, purgeDeletedChats, arg.DeletedBefore, arg.BatchSize)
+	var i PurgeDeletedChatsRow
+	err := row.Scan(
+		&i.Chats,
+		&i.Messages,
+	)
+	return i, err
+}
+
+const updateChat = `-- name: UpdateChat :one
+UPDATE chats
+SET title = COALESCE($1, title),
+    pinned = COALESCE($2, pinned),
+    archived = COALESCE($3, archived),
+    updated_at = $4
+WHERE id = $5 AND deleted_at IS NULL
+RETURNING id, title, user_email, last_active_date, created_at, updated_at, title_tsv, pinned, archived, deleted_at, tenant
+`
+
+type UpdateChatParams struct {
+	Title     sql.NullString
+	Pinned    sql.NullBool
+	Archived  sql.NullBool
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Fields that are not set keep their value
+func (q *Queries) UpdateChat(ctx context.Context, arg UpdateChatParams) (Chat, error) {
+	row := q.db.QueryRowContext(ctx, updateChat,
+		arg.Title,
+		arg.Pinned,
+		arg.Archived,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i Chat
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.UserEmail,
+		&i.LastActiveDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TitleTsv,
+		&i.Pinned,
+		&i.Archived,
+		&i.DeletedAt,
+		&i.Tenant,
+	)
+	return i, err
+}
+
 const updateChatLastActive = `-- name: UpdateChatLastActive :exec
 UPDATE chats
 SET last_active_date = $2, updated_at = $3
@@ -119,3 +355,42 @@ func (q *Queries) UpdateChatLastActive(ctx context.Context, arg UpdateChatLastAc
 	_, err := q.db.ExecContext(ctx, updateChatLastActive, arg.ID, arg.LastActiveDate, arg.UpdatedAt)
 	return err
 }
+
+const updateChatTitle = `-- name: UpdateChatTitle :one
+UPDATE chats
+SET title = $1, updated_at = $2
+WHERE id = $3 AND title = $4 AND deleted_at IS NULL
+RETURNING id, title, user_email, last_active_date, created_at, updated_at, title_tsv, pinned, archived, deleted_at, tenant
+`
+
+type UpdateChatTitleParams struct {
+	Title         string
+	UpdatedAt     time.Time
+	ID            uuid.UUID
+	PreviousTitle string
+}
+
+// Only replaces the title the new one was generated for, a rename by the user in the meantime is kept
+func (q *Queries) UpdateChatTitle(ctx context.Context, arg UpdateChatTitleParams) (Chat, error) {
+	row := q.db.QueryRowContext(ctx, updateChatTitle,
+		arg.Title,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.PreviousTitle,
+	)
+	var i Chat
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.UserEmail,
+		&i.LastActiveDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TitleTsv,
+		&i.Pinned,
+		&i.Archived,
+		&i.DeletedAt,
+		&i.Tenant,
+	)
+	return i, err
+}
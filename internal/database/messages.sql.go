@@ -10,23 +10,104 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const activateMessage = `-- name: ActivateMessage :exec
+UPDATE messages
+SET active = TRUE, updated_at = $2
+WHERE id = $1 AND NOT active
+`
+
+type ActivateMessageParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) ActivateMessage(ctx context.Context, arg ActivateMessageParams) error {
+	_, err := q.db.ExecContext(ctx, activateMessage, arg.ID, arg.UpdatedAt)
+	return err
+}
+
+const attachToolCalls = `-- name: AttachToolCalls :exec
+UPDATE messages
+SET parent_id = $1
+WHERE id = ANY($2::uuid[])
+`
+
+type AttachToolCallsParams struct {
+	ReplyID uuid.NullUUID
+	Ids     []uuid.UUID
+}
+
+// Moves the records of the tool calls made while generating a reply below the reply
+func (q *Queries) AttachToolCalls(ctx context.Context, arg AttachToolCallsParams) error {
+	_, err := q.db.ExecContext(ctx, attachToolCalls, arg.ReplyID, pq.Array(arg.Ids))
+	return err
+}
+
+const countVariantsByChatID = `-- name: CountVariantsByChatID :many
+SELECT parent_id, sender_type, COUNT(*) AS variants FROM messages
+WHERE chat_id = $1
+GROUP BY parent_id, sender_type
+`
+
+type CountVariantsByChatIDRow struct {
+	ParentID   uuid.NullUUID
+	SenderType string
+	Variants   int64
+}
+
+// Number of siblings per parent and sender type in the chat
+func (q *Queries) CountVariantsByChatID(ctx context.Context, chatID uuid.UUID) ([]CountVariantsByChatIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, countVariantsByChatID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountVariantsByChatIDRow
+	for rows.Next() {
+		var i CountVariantsByChatIDRow
+		if err := rows.Scan(
+			&i.ParentID,
+			&i.SenderType,
+			&i.Variants,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createMessage = `-- name: CreateMessage :one
-INSERT INTO messages (id, content, sender_type, chat_id, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, content, sender_type, chat_id, created_at, updated_at
+INSERT INTO messages (id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, parent_id, variant)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+    (SELECT COUNT(*) + 1 FROM messages
+     WHERE chat_id = $4 AND parent_id IS NOT DISTINCT FROM $9 AND sender_type = $3))
+RETURNING id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, content_tsv, parent_id, variant, active
 `
 
 type CreateMessageParams struct {
-	ID         uuid.UUID
-	Content    string
-	SenderType string
-	ChatID     uuid.UUID
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID               uuid.UUID
+	Content          string
+	SenderType       string
+	ChatID           uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Incomplete       bool
+	PromptTemplateID uuid.NullUUID
+	ParentID         uuid.NullUUID
 }
 
+// The message is numbered as next variant among its siblings of the same sender type. Concurrent messages with the same
+// parent only get distinct numbers if the chat is locked with LockChat in the same transaction.
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
 	row := q.db.QueryRowContext(ctx, createMessage,
 		arg.ID,
@@ -35,6 +116,9 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		arg.ChatID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		arg.Incomplete,
+		arg.PromptTemplateID,
+		arg.ParentID,
 	)
 	var i Message
 	err := row.Scan(
@@ -44,12 +128,110 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.ChatID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Incomplete,
+		&i.PromptTemplateID,
+		&i.ContentTsv,
+		&i.ParentID,
+		&i.Variant,
+		&i.Active,
 	)
 	return i, err
 }
 
+const deactivateSiblings = `-- name: DeactivateSiblings :exec
+UPDATE messages
+SET active = FALSE, updated_at = $1
+WHERE chat_id = $2 AND parent_id IS NOT DISTINCT FROM $3
+    AND id <> $4 AND sender_type <> 'backend' AND active
+`
+
+type DeactivateSiblingsParams struct {
+	UpdatedAt time.Time
+	ChatID    uuid.UUID
+	ParentID  uuid.NullUUID
+	ID        uuid.UUID
+}
+
+// Takes the siblings out of the conversation when a message becomes active, tool call records stay attached
+func (q *Queries) DeactivateSiblings(ctx context.Context, arg DeactivateSiblingsParams) error {
+	_, err := q.db.ExecContext(ctx, deactivateSiblings,
+		arg.UpdatedAt,
+		arg.ChatID,
+		arg.ParentID,
+		arg.ID,
+	)
+	return err
+}
+
+const getActivePathAfter = `-- name: GetActivePathAfter :many
+WITH RECURSIVE active_path AS (
+    (SELECT m.id, m.content, m.sender_type, m.chat_id, m.created_at, m.updated_at, m.incomplete, m.prompt_template_id, m.content_tsv, m.parent_id, m.variant, m.active, 1 AS depth FROM messages m
+    WHERE m.chat_id = $1 AND m.parent_id IS NOT DISTINCT FROM $2
+        AND (m.parent_id IS NULL OR m.sender_type <> 'backend')
+    ORDER BY m.active DESC, m.created_at DESC
+    LIMIT 1)
+    UNION ALL
+    SELECT next.id, next.content, next.sender_type, next.chat_id, next.created_at, next.updated_at, next.incomplete, next.prompt_template_id, next.content_tsv, next.parent_id, next.variant, next.active, p.depth + 1 FROM active_path p
+    CROSS JOIN LATERAL (
+        SELECT m.id, m.content, m.sender_type, m.chat_id, m.created_at, m.updated_at, m.incomplete, m.prompt_template_id, m.content_tsv, m.parent_id, m.variant, m.active FROM messages m
+        WHERE m.parent_id = p.id AND m.sender_type <> 'backend'
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1
+    ) next
+    WHERE p.depth < $3
+)
+SELECT id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, content_tsv, parent_id, variant, active
+FROM active_path
+ORDER BY depth
+`
+
+type GetActivePathAfterParams struct {
+	ChatID   uuid.UUID
+	AfterID  uuid.NullUUID
+	PageSize int32
+}
+
+// The next messages of the active conversation after the message, from its start if after_id is NULL. Follows the
+// active continuation of every message, the latest one if none is active, like the message tree of the API. Tool call
+// records are not continuations and are left out. Pages through long conversations with the last message as cursor.
+func (q *Queries) GetActivePathAfter(ctx context.Context, arg GetActivePathAfterParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, getActivePathAfter, arg.ChatID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.SenderType,
+			&i.ChatID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Incomplete,
+			&i.PromptTemplateID,
+			&i.ContentTsv,
+			&i.ParentID,
+			&i.Variant,
+			&i.Active,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMessage = `-- name: GetMessage :one
-SELECT id, content, sender_type, chat_id, created_at, updated_at FROM messages
+SELECT id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, content_tsv, parent_id, variant, active FROM messages
 WHERE id = $1 LIMIT 1
 `
 
@@ -63,16 +245,23 @@ func (q *Queries) GetMessage(ctx context.Context, id uuid.UUID) (Message, error)
 		&i.ChatID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Incomplete,
+		&i.PromptTemplateID,
+		&i.ContentTsv,
+		&i.ParentID,
+		&i.Variant,
+		&i.Active,
 	)
 	return i, err
 }
 
 const getMessagesByChatID = `-- name: GetMessagesByChatID :many
-SELECT id, content, sender_type, chat_id, created_at, updated_at FROM messages
+SELECT id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, content_tsv, parent_id, variant, active FROM messages
 WHERE chat_id = $1
 ORDER BY created_at ASC
 `
 
+// All messages of the chat including inactive branches, the conversation is the active path through them
 func (q *Queries) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID) ([]Message, error) {
 	rows, err := q.db.QueryContext(ctx, getMessagesByChatID, chatID)
 	if err != nil {
@@ -89,6 +278,12 @@ func (q *Queries) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID) ([]
 			&i.ChatID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Incomplete,
+			&i.PromptTemplateID,
+			&i.ContentTsv,
+			&i.ParentID,
+			&i.Variant,
+			&i.Active,
 		); err != nil {
 			return nil, err
 		}
@@ -5,6 +5,7 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,13 +18,130 @@ type Chat struct {
 	LastActiveDate time.Time
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	TitleTsv       interface{}
+	Pinned         bool
+	Archived       bool
+	DeletedAt      sql.NullTime
+	Tenant         string
 }
 
-type Message struct {
+type ChatImport struct {
+	UserEmail  string
+	Source     string
+	ExternalID string
+	ChatID     uuid.UUID
+	ImportedAt time.Time
+}
+
+type ChatSummary struct {
+	ChatID        uuid.UUID
+	Content       string
+	LastMessageID uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type DataErasure struct {
+	ID          uuid.UUID
+	SubjectHash string
+	InitiatedBy string
+	Chats       int32
+	Messages    int32
+	TokenUsage  int32
+	ErasedAt    time.Time
+}
+
+type KbChunk struct {
 	ID         uuid.UUID
+	DocumentID uuid.UUID
+	Position   int32
 	Content    string
-	SenderType string
-	ChatID     uuid.UUID
 	CreatedAt  time.Time
-	UpdatedAt  time.Time
+}
+
+type KbDocument struct {
+	ID          uuid.UUID
+	Source      string
+	Title       string
+	Format      string
+	Content     string
+	ContentHash string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type KbIngestionJob struct {
+	ID             uuid.UUID
+	DocumentID     uuid.NullUUID
+	Source         string
+	Kind           string
+	Status         string
+	Error          string
+	Chunks         int32
+	EmbeddedChunks int32
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type Message struct {
+	ID               uuid.UUID
+	Content          string
+	SenderType       string
+	ChatID           uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Incomplete       bool
+	PromptTemplateID uuid.NullUUID
+	ContentTsv       interface{}
+	ParentID         uuid.NullUUID
+	Variant          int32
+	Active           bool
+}
+
+type MessageSource struct {
+	MessageID  uuid.UUID
+	Position   int32
+	DocumentID uuid.NullUUID
+	Title      string
+	Excerpt    string
+	Score      float64
+}
+
+type PromptTemplate struct {
+	ID        uuid.UUID
+	Name      string
+	Version   int32
+	Content   string
+	CreatedAt time.Time
+}
+
+type PurgeRun struct {
+	ID              uuid.UUID
+	DryRun          bool
+	DeletedChats    int32
+	InactiveChats   int32
+	Messages        int32
+	Error           sql.NullString
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	ExpiredMessages int32
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt time.Time
+}
+
+type TokenUsage struct {
+	ID               uuid.UUID
+	MessageID        uuid.NullUUID
+	ChatID           uuid.NullUUID
+	UserEmail        string
+	Tenant           string
+	Model            string
+	PromptTokens     int32
+	CompletionTokens int32
+	CreatedAt        time.Time
 }
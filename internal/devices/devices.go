@@ -0,0 +1,152 @@
+package devices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+)
+
+// ErrDeviceNotFound is returned for devices that don't exist or don't belong to the user
+var ErrDeviceNotFound = errors.New("device not found")
+
+// Device is a device registered to a user
+type Device struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Model           string    `json:"model"`
+	SerialNumber    string    `json:"serialNumber"`
+	IPAddress       string    `json:"ipAddress"`
+	FirmwareVersion string    `json:"firmwareVersion"`
+	Status          string    `json:"status"`
+	LastSeen        time.Time `json:"lastSeen"`
+}
+
+// Directory looks up the devices registered to a user
+type Directory interface {
+	// List returns the devices of the user
+	List(ctx context.Context, userEmail string) ([]Device, error)
+	// Get returns a device of the user by its id, serial number or name
+	Get(ctx context.Context, userEmail, key string) (Device, error)
+}
+
+// NewDirectory creates the device directory selected in the configuration
+func NewDirectory(cfg config.ToolsConfig) (Directory, error) {
+	switch cfg.DeviceDirectory {
+	case "", "mock":
+		return MockDirectory{}, nil
+	case "http":
+		return NewHTTPDirectory(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown device directory %q", cfg.DeviceDirectory)
+	}
+}
+
+// MockDirectory returns the same two sample devices for every user, for local development
+type MockDirectory struct{}
+
+func (d MockDirectory) List(ctx context.Context, userEmail string) ([]Device, error) {
+	lastSeen := time.Now().UTC().Truncate(time.Minute)
+	return []Device{
+		{
+			ID:              "gw-001",
+			Name:            "Office Gateway",
+			Model:           "M2MDevice Gate X1",
+			SerialNumber:    "X1-2023-004211",
+			IPAddress:       "10.20.0.14",
+			FirmwareVersion: "2.3.7",
+			Status:          "online",
+			LastSeen:        lastSeen,
+		},
+		{
+			ID:              "gw-002",
+			Name:            "Warehouse Gateway",
+			Model:           "M2MDevice Gate X2",
+			SerialNumber:    "X2-2024-000815",
+			IPAddress:       "10.20.4.2",
+			FirmwareVersion: "3.0.1",
+			Status:          "offline",
+			LastSeen:        lastSeen.Add(-26 * time.Hour),
+		},
+	}, nil
+}
+
+func (d MockDirectory) Get(ctx context.Context, userEmail, key string) (Device, error) {
+	devices, _ := d.List(ctx, userEmail)
+	for _, device := range devices {
+		if matches(device, key) {
+			return device, nil
+		}
+	}
+	return Device{}, ErrDeviceNotFound
+}
+
+// matches reports whether the key identifies the device, names are compared case-insensitively
+func matches(device Device, key string) bool {
+	return device.ID == key || device.SerialNumber == key || strings.EqualFold(device.Name, key)
+}
+
+// HTTPDirectory reads the devices from the device management API:
+// GET /users/{email}/devices returns the devices of a user as JSON array
+type HTTPDirectory struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPDirectory creates a directory for the device management API in the configuration
+func NewHTTPDirectory(cfg config.ToolsConfig) *HTTPDirectory {
+	return &HTTPDirectory{
+		baseURL: strings.TrimSuffix(cfg.DeviceAPIURL, "/"),
+		apiKey:  cfg.DeviceAPIKey,
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (d *HTTPDirectory) List(ctx context.Context, userEmail string) ([]Device, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/users/"+url.PathEscape(userEmail)+"/devices", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("device API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var devices []Device
+	if err := json.NewDecoder(resp.Body).Decode(&devices); err != nil {
+		return nil, fmt.Errorf("decoding devices: %w", err)
+	}
+	return devices, nil
+}
+
+// Get filters the devices of the user, so a device of another user is never returned
+func (d *HTTPDirectory) Get(ctx context.Context, userEmail, key string) (Device, error) {
+	devices, err := d.List(ctx, userEmail)
+	if err != nil {
+		return Device{}, err
+	}
+	for _, device := range devices {
+		if matches(device, key) {
+			return device, nil
+		}
+	}
+	return Device{}, ErrDeviceNotFound
+}
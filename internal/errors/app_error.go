@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AppError is an application error carrying the error code, details and the underlying cause.
+// Handlers return it instead of a fiber.Error when the client needs more than a status code.
+type AppError struct {
+	// HTTP status code, derived from the error code
+	Status int
+	// Error code returned to the client
+	Code ErrorCode
+	// Human-readable error description returned to the client
+	Message string
+	// Field-value pairs with additional error information
+	Details []ErrorDetail
+	// RetryAfter is sent as Retry-After header if set
+	RetryAfter time.Duration
+	// Cause is the underlying error, it is logged but never returned to the client
+	Cause error
+}
+
+// NewAppError creates an application error for the given code
+func NewAppError(code ErrorCode, message string, details ...ErrorDetail) *AppError {
+	return &AppError{
+		Status:  StatusForCode(code),
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+// WrapAppError creates an application error for the given code caused by err
+func WrapAppError(code ErrorCode, message string, err error) *AppError {
+	appErr := NewAppError(code, message)
+	appErr.Cause = err
+	return appErr
+}
+
+// WithRetryAfter sets the delay after which the client may retry the request
+func (e *AppError) WithRetryAfter(retryAfter time.Duration) *AppError {
+	e.RetryAfter = retryAfter
+	return e
+}
+
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Response converts the error into the response returned to the client
+func (e *AppError) Response() ErrorResponse {
+	response := responseForCode(e.Code, e.Message)
+	response.Details = e.Details
+	return response
+}
+
+// StatusForCode returns the HTTP status code of an error code
+func StatusForCode(code ErrorCode) int {
+	switch code {
+	case ValidationError:
+		return fiber.StatusBadRequest
+	case UnauthorizedError:
+		return fiber.StatusUnauthorized
+	case ForbiddenError:
+		return fiber.StatusForbidden
+	case ResourceNotFoundError:
+		return fiber.StatusNotFound
+	case ConflictError:
+		return fiber.StatusConflict
+	case PayloadTooLargeError:
+		return fiber.StatusRequestEntityTooLarge
+	case UnprocessableError:
+		return fiber.StatusUnprocessableEntity
+	case RateLimitedError, QuotaExceededError:
+		return fiber.StatusTooManyRequests
+	case UpstreamError:
+		return fiber.StatusBadGateway
+	case UnavailableError:
+		return fiber.StatusServiceUnavailable
+	case UpstreamTimeoutError:
+		return fiber.StatusGatewayTimeout
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// responseForCode creates the error response for an error code, falling back to the default message
+func responseForCode(code ErrorCode, message string) ErrorResponse {
+	switch code {
+	case ValidationError:
+		return NewValidationError(message)
+	case UnauthorizedError:
+		return NewUnauthorizedError(message)
+	case ForbiddenError:
+		return NewForbiddenError(message)
+	case ResourceNotFoundError:
+		return NewResourceNotFoundError(message)
+	case ConflictError:
+		return NewConflictError(message)
+	case PayloadTooLargeError:
+		return NewPayloadTooLargeError(message)
+	case UnprocessableError:
+		return NewUnprocessableError(message)
+	case RateLimitedError:
+		return NewRateLimitedError(message)
+	case QuotaExceededError:
+		return NewQuotaExceededError(message)
+	case UpstreamError:
+		return NewUpstreamError(message)
+	case UnavailableError:
+		return NewUnavailableError(message)
+	case UpstreamTimeoutError:
+		return NewUpstreamTimeoutError(message)
+	case ServerError:
+		return NewServerError(message)
+	default:
+		return NewErrorResponse(code, message)
+	}
+}
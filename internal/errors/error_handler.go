@@ -3,12 +3,26 @@ package errors
 import (
 	"errors"
 	"log"
+	"math"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // ErrorHandler is a custom Fiber error handler
 func ErrorHandler(c *fiber.Ctx, err error) error {
+	// Application errors carry their own code and details
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if appErr.Status >= fiber.StatusInternalServerError {
+			log.Printf("Request failed: %v", appErr)
+		}
+		if appErr.RetryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+		return Respond(c, appErr.Status, appErr.Response())
+	}
+
 	// Get the status code from the error, default to 500
 	code := fiber.StatusInternalServerError
 
@@ -29,13 +43,26 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		response = NewForbiddenError(err.Error())
 	case fiber.StatusNotFound:
 		response = NewResourceNotFoundError(err.Error())
+	case fiber.StatusConflict:
+		response = NewConflictError(err.Error())
+	case fiber.StatusRequestEntityTooLarge:
+		response = NewPayloadTooLargeError(err.Error())
+	case fiber.StatusUnprocessableEntity:
+		response = NewUnprocessableError(err.Error())
+	case fiber.StatusTooManyRequests:
+		response = NewRateLimitedError(err.Error())
+	case fiber.StatusBadGateway:
+		response = NewUpstreamError(err.Error())
+	case fiber.StatusServiceUnavailable:
+		response = NewUnavailableError(err.Error())
+	case fiber.StatusGatewayTimeout:
+		response = NewUpstreamTimeoutError(err.Error())
 	default:
 		// Log unexpected errors
 		log.Printf("Unexpected error: %v", err)
 		response = NewServerError("An unexpected error occurred")
 	}
 
-	// Set the content type and return the error
-	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
-	return c.Status(code).JSON(response)
+	// Return the error in the format negotiated with the client
+	return Respond(c, code, response)
 }
@@ -13,8 +13,24 @@ const (
 	ForbiddenError ErrorCode = "FORBIDDEN"
 	// ResourceNotFoundError indicates that the requested resource was not found
 	ResourceNotFoundError ErrorCode = "RESOURCE_NOT_FOUND"
+	// ConflictError indicates that the request conflicts with the current state of the resource
+	ConflictError ErrorCode = "CONFLICT"
+	// PayloadTooLargeError indicates that the request body exceeds the allowed size
+	PayloadTooLargeError ErrorCode = "PAYLOAD_TOO_LARGE"
+	// UnprocessableError indicates that the request is well-formed but cannot be processed
+	UnprocessableError ErrorCode = "UNPROCESSABLE_ENTITY"
+	// RateLimitedError indicates that the client sent too many requests
+	RateLimitedError ErrorCode = "RATE_LIMITED"
+	// QuotaExceededError indicates that the monthly token quota of the user or tenant is used up
+	QuotaExceededError ErrorCode = "QUOTA_EXCEEDED"
 	// ServerError indicates an unexpected server error
 	ServerError ErrorCode = "SERVER_ERROR"
+	// UpstreamError indicates that the LLM provider failed
+	UpstreamError ErrorCode = "UPSTREAM_ERROR"
+	// UnavailableError indicates that the service is temporarily unavailable, e.g. shutting down
+	UnavailableError ErrorCode = "SERVICE_UNAVAILABLE"
+	// UpstreamTimeoutError indicates that the LLM provider did not answer in time
+	UpstreamTimeoutError ErrorCode = "UPSTREAM_TIMEOUT"
 )
 
 // ErrorDetail represents details about validation errors
@@ -89,3 +105,67 @@ func NewServerError(message string) ErrorResponse {
 	}
 	return NewErrorResponse(ServerError, message)
 }
+
+// NewConflictError creates a conflict error response
+func NewConflictError(message string, details ...ErrorDetail) ErrorResponse {
+	if message == "" {
+		message = "The request conflicts with the current state of the resource"
+	}
+	return NewErrorResponse(ConflictError, message, details...)
+}
+
+// NewPayloadTooLargeError creates a payload too large error response
+func NewPayloadTooLargeError(message string, details ...ErrorDetail) ErrorResponse {
+	if message == "" {
+		message = "The request body is too large"
+	}
+	return NewErrorResponse(PayloadTooLargeError, message, details...)
+}
+
+// NewUnprocessableError creates an unprocessable entity error response
+func NewUnprocessableError(message string, details ...ErrorDetail) ErrorResponse {
+	if message == "" {
+		message = "The request could not be processed"
+	}
+	return NewErrorResponse(UnprocessableError, message, details...)
+}
+
+// NewRateLimitedError creates a rate limited error response
+func NewRateLimitedError(message string) ErrorResponse {
+	if message == "" {
+		message = "Too many requests, please try again later"
+	}
+	return NewErrorResponse(RateLimitedError, message)
+}
+
+// NewQuotaExceededError creates a quota exceeded error response
+func NewQuotaExceededError(message string) ErrorResponse {
+	if message == "" {
+		message = "The monthly token quota has been used up"
+	}
+	return NewErrorResponse(QuotaExceededError, message)
+}
+
+// NewUpstreamError creates an error response for a failed LLM provider
+func NewUpstreamError(message string) ErrorResponse {
+	if message == "" {
+		message = "The AI provider failed to generate a response"
+	}
+	return NewErrorResponse(UpstreamError, message)
+}
+
+// NewUnavailableError creates a service unavailable error response
+func NewUnavailableError(message string) ErrorResponse {
+	if message == "" {
+		message = "The service is temporarily unavailable"
+	}
+	return NewErrorResponse(UnavailableError, message)
+}
+
+// NewUpstreamTimeoutError creates an error response for an LLM provider that did not answer in time
+func NewUpstreamTimeoutError(message string) ErrorResponse {
+	if message == "" {
+		message = "The AI provider did not respond in time"
+	}
+	return NewErrorResponse(UpstreamTimeoutError, message)
+}
@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemTypeBaseURI is the prefix of the problem type URIs, one per ErrorCode
+var ProblemTypeBaseURI = "https://inside-m2m.de/problems/"
+
+// ProblemDetails represents an RFC 7807 problem details response
+// swagger:model ProblemDetails
+type ProblemDetails struct {
+	// URI identifying the problem type
+	// example: https://inside-m2m.de/problems/validation-error
+	Type string `json:"type"`
+
+	// Short, human-readable summary of the problem type
+	// example: Validation Error
+	Title string `json:"title"`
+
+	// HTTP status code
+	// example: 400
+	Status int `json:"status"`
+
+	// Human-readable explanation specific to this occurrence of the problem
+	// example: Content cannot be empty
+	Detail string `json:"detail,omitempty"`
+
+	// Request path the problem occurred on
+	// example: /v1/chats
+	Instance string `json:"instance,omitempty"`
+
+	// Error code that identifies the error type, same as in ErrorResponse
+	// example: VALIDATION_ERROR
+	Code ErrorCode `json:"code"`
+
+	// Identifier to correlate the problem with logs and traces
+	// example: 4bf92f3577b34da6a3ce929d0e0e4736
+	TraceID string `json:"traceId,omitempty"`
+
+	// List of field-value pairs with additional error information
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ProblemType returns the problem type URI of an error code
+func ProblemType(code ErrorCode) string {
+	return ProblemTypeBaseURI + strings.ToLower(strings.ReplaceAll(string(code), "_", "-"))
+}
+
+// ProblemTitle returns the problem title of an error code, e.g. "Resource Not Found"
+func ProblemTitle(code ErrorCode) string {
+	words := strings.Split(strings.ToLower(string(code)), "_")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// NewProblemDetails converts an error response into problem details for the current request
+func NewProblemDetails(c *fiber.Ctx, status int, response ErrorResponse) ProblemDetails {
+	return ProblemDetails{
+		Type:     ProblemType(response.Code),
+		Title:    ProblemTitle(response.Code),
+		Status:   status,
+		Detail:   response.Message,
+		Instance: c.Path(),
+		Code:     response.Code,
+		TraceID:  traceID(c),
+		Details:  response.Details,
+	}
+}
+
+// Respond writes the error response in the format negotiated with the client.
+// ErrorResponse is the default, problem details are returned if the client prefers them.
+func Respond(c *fiber.Ctx, status int, response ErrorResponse) error {
+	if c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON) == MIMEApplicationProblemJSON {
+		return c.Status(status).JSON(NewProblemDetails(c, status, response), MIMEApplicationProblemJSON)
+	}
+	return c.Status(status).JSON(response)
+}
+
+// traceID returns the W3C trace id of the request if present, otherwise the request id
+func traceID(c *fiber.Ctx) string {
+	// traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(c.Get("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	if requestID, ok := c.Locals("requestid").(string); ok && requestID != "" {
+		return requestID
+	}
+	if requestID := c.GetRespHeader(fiber.HeaderXRequestID); requestID != "" {
+		return requestID
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
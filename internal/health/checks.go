@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ai-chat-service-go/internal/services"
+)
+
+// DatabaseCheck pings the database
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// MigrationCheck verifies that the latest migration in migrationDir has been applied by goose
+func MigrationCheck(db *sql.DB, migrationDir string) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			expected, err := latestMigrationVersion(migrationDir)
+			if err != nil {
+				return err
+			}
+
+			var applied int64
+			err = db.QueryRowContext(ctx,
+				"SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied",
+			).Scan(&applied)
+			if err != nil {
+				return fmt.Errorf("reading migration version: %w", err)
+			}
+
+			if applied < expected {
+				return fmt.Errorf("database is at migration %d, expected %d", applied, expected)
+			}
+			return nil
+		},
+	}
+}
+
+// LLMCheck pings the configured LLM provider
+func LLMCheck(provider services.LLMProvider) Check {
+	return Check{
+		Name: "llm:" + provider.Name(),
+		Run:  provider.Ping,
+	}
+}
+
+// latestMigrationVersion returns the highest goose version found in the migration directory
+func latestMigrationVersion(dir string) (int64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	var latest int64
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
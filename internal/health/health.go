@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status values reported by the health endpoints
+const (
+	StatusOK       = "ok"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+	StatusUp       = "up"
+	StatusDown     = "down"
+)
+
+// Check is a single dependency check run by the readiness endpoint
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single dependency check
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Response is returned by the liveness and readiness endpoints
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker serves the liveness and readiness endpoints
+type Checker struct {
+	timeout  time.Duration
+	checks   []Check
+	draining atomic.Bool
+}
+
+// NewChecker creates a checker running every check with the given timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+// SetDraining marks the instance as shutting down so it is taken out of load balancing
+func (h *Checker) SetDraining(draining bool) {
+	h.draining.Store(draining)
+}
+
+// Draining reports whether the instance is shutting down
+func (h *Checker) Draining() bool {
+	return h.draining.Load()
+}
+
+// Liveness reports that the process is alive and able to serve requests
+func (h *Checker) Liveness(c *fiber.Ctx) error {
+	return c.JSON(Response{Status: StatusOK})
+}
+
+// Readiness checks all dependencies and reports 503 if any of them is unavailable
+func (h *Checker) Readiness(c *fiber.Ctx) error {
+	results := h.run(c.Context())
+
+	response := Response{Status: StatusReady, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			response.Status = StatusNotReady
+		}
+	}
+	if h.Draining() {
+		response.Status = StatusDraining
+	}
+
+	if response.Status != StatusReady {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
+
+// run executes all checks concurrently, each with its own timeout
+func (h *Checker) run(ctx context.Context) map[string]CheckResult {
+	results := make(map[string]CheckResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := runWithContext(checkCtx, check.Run)
+			result := CheckResult{Status: StatusUp, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// runWithContext returns as soon as ctx expires, even if the check ignores its context
+func runWithContext(ctx context.Context, run func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() { done <- run(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
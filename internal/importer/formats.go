@@ -0,0 +1,324 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format is the kind of archive chats are imported from
+type Format string
+
+const (
+	// NodeFormat is the export of the Node.js variant of this service: {"chats": [...]} or a plain array of chats
+	// with their messages, shaped like the ChatDTO and MessageDTO of the API
+	NodeFormat Format = "node"
+	// ChatGPTFormat is the conversations.json of a ChatGPT data export, every conversation is a tree of messages
+	ChatGPTFormat Format = "chatgpt"
+)
+
+// Archive holds the chats read from an archive
+type Archive struct {
+	Format Format
+	Chats  []Chat
+}
+
+// Chat is a chat read from an archive. Dates missing in the archive are zero.
+type Chat struct {
+	ExternalID     string
+	Title          string
+	UserEmail      string
+	CreatedAt      time.Time
+	LastActiveDate time.Time
+	Messages       []Message
+	// Err is set if the chat could not be read, it is reported as failed
+	Err error
+}
+
+// Message is a message read from an archive, the role is the sender as named in the archive
+type Message struct {
+	ExternalID string
+	Role       string
+	Content    string
+	CreatedAt  time.Time
+}
+
+// Parse reads the chats from an archive, the format is detected if empty. Chats that can't be read
+// are returned with an error so the other chats of the archive can still be imported.
+func Parse(data []byte, format Format) (Archive, error) {
+	data = bytes.TrimSpace(data)
+	if format == "" {
+		format = detectFormat(data)
+	}
+	switch format {
+	case NodeFormat:
+		chats, err := parseNode(data)
+		return Archive{Format: format, Chats: chats}, err
+	case ChatGPTFormat:
+		chats, err := parseChatGPT(data)
+		return Archive{Format: format, Chats: chats}, err
+	default:
+		return Archive{}, errors.New("the format of the archive could not be detected, pass it explicitly")
+	}
+}
+
+// detectFormat tells the formats apart by their structure: ChatGPT conversations have a message mapping,
+// Node.js chats a list of messages
+func detectFormat(data []byte) Format {
+	if bytes.HasPrefix(data, []byte("{")) {
+		return NodeFormat
+	}
+	var probe []struct {
+		Mapping  json.RawMessage `json:"mapping"`
+		Messages json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return ""
+	}
+	switch {
+	case len(probe) == 0:
+		// Reported as archive without chats
+		return NodeFormat
+	case probe[0].Mapping != nil:
+		return ChatGPTFormat
+	case probe[0].Messages != nil:
+		return NodeFormat
+	default:
+		return ""
+	}
+}
+
+// rawChats splits the archive into its chats, each chat is decoded on its own
+func rawChats(data []byte) ([]json.RawMessage, error) {
+	var chats []json.RawMessage
+	if bytes.HasPrefix(data, []byte("{")) {
+		var archive struct {
+			Chats []json.RawMessage `json:"chats"`
+		}
+		if err := json.Unmarshal(data, &archive); err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		chats = archive.Chats
+	} else if err := json.Unmarshal(data, &chats); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	if len(chats) == 0 {
+		return nil, errors.New("the archive contains no chats")
+	}
+	return chats, nil
+}
+
+type nodeChat struct {
+	ID             string        `json:"id"`
+	Title          string        `json:"title"`
+	UserEmail      string        `json:"userEmail"`
+	CreatedAt      *time.Time    `json:"createdAt"`
+	LastActiveDate *time.Time    `json:"lastActiveDate"`
+	Messages       []nodeMessage `json:"messages"`
+}
+
+type nodeMessage struct {
+	ID         string     `json:"id"`
+	Content    string     `json:"content"`
+	SenderType string     `json:"senderType"`
+	Role       string     `json:"role"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
+
+func parseNode(data []byte) ([]Chat, error) {
+	raw, err := rawChats(data)
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]Chat, 0, len(raw))
+	for i, entry := range raw {
+		var decoded nodeChat
+		if err := json.Unmarshal(entry, &decoded); err != nil {
+			chats = append(chats, Chat{ExternalID: externalID(entry), Err: fmt.Errorf("chat %d is invalid: %w", i+1, err)})
+			continue
+		}
+		chat := Chat{
+			ExternalID:     decoded.ID,
+			Title:          decoded.Title,
+			UserEmail:      decoded.UserEmail,
+			CreatedAt:      timeOrZero(decoded.CreatedAt),
+			LastActiveDate: timeOrZero(decoded.LastActiveDate),
+		}
+		for _, message := range decoded.Messages {
+			role := message.SenderType
+			if role == "" {
+				role = message.Role
+			}
+			chat.Messages = append(chat.Messages, Message{
+				ExternalID: message.ID,
+				Role:       role,
+				Content:    message.Content,
+				CreatedAt:  timeOrZero(message.CreatedAt),
+			})
+		}
+		chats = append(chats, chat)
+	}
+	return chats, nil
+}
+
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     *float64               `json:"create_time"`
+	UpdateTime     *float64               `json:"update_time"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+	CurrentNode    string                 `json:"current_node"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Message  *chatGPTMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatGPTMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+	} `json:"content"`
+	Metadata struct {
+		Hidden bool `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+func parseChatGPT(data []byte) ([]Chat, error) {
+	raw, err := rawChats(data)
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]Chat, 0, len(raw))
+	for i, entry := range raw {
+		var decoded chatGPTConversation
+		if err := json.Unmarshal(entry, &decoded); err != nil {
+			chats = append(chats, Chat{ExternalID: externalID(entry), Err: fmt.Errorf("conversation %d is invalid: %w", i+1, err)})
+			continue
+		}
+		chat := Chat{
+			ExternalID:     decoded.ID,
+			Title:          decoded.Title,
+			CreatedAt:      unixTime(decoded.CreateTime),
+			LastActiveDate: unixTime(decoded.UpdateTime),
+		}
+		if chat.ExternalID == "" {
+			chat.ExternalID = decoded.ConversationID
+		}
+		// Only the branch that was shown last is imported, empty and hidden nodes are structure of the export
+		for _, node := range decoded.currentBranch() {
+			message := node.Message
+			if message == nil || message.Metadata.Hidden {
+				continue
+			}
+			content := message.text()
+			if content == "" {
+				continue
+			}
+			chat.Messages = append(chat.Messages, Message{
+				ExternalID: message.ID,
+				Role:       message.Author.Role,
+				Content:    content,
+				CreatedAt:  unixTime(message.CreateTime),
+			})
+		}
+		chats = append(chats, chat)
+	}
+	return chats, nil
+}
+
+// currentBranch follows the parents from the current node to the root, a conversation without current node
+// follows the last children from the root instead
+func (c chatGPTConversation) currentBranch() []chatGPTNode {
+	var branch []chatGPTNode
+	if node, ok := c.Mapping[c.CurrentNode]; ok {
+		for len(branch) < len(c.Mapping) {
+			branch = append(branch, node)
+			if node, ok = c.Mapping[node.Parent]; !ok {
+				break
+			}
+		}
+		slices.Reverse(branch)
+		return branch
+	}
+
+	for _, node := range c.Mapping {
+		if _, ok := c.Mapping[node.Parent]; ok {
+			continue
+		}
+		for len(branch) < len(c.Mapping) {
+			branch = append(branch, node)
+			next, ok := c.Mapping[lastChild(node)]
+			if !ok {
+				break
+			}
+			node = next
+		}
+		break
+	}
+	return branch
+}
+
+func lastChild(node chatGPTNode) string {
+	if len(node.Children) == 0 {
+		return ""
+	}
+	return node.Children[len(node.Children)-1]
+}
+
+// text joins the text parts of the message, images and other attachments are left out
+func (m *chatGPTMessage) text() string {
+	var parts []string
+	for _, part := range m.Content.Parts {
+		var text string
+		if json.Unmarshal(part, &text) == nil && strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 && strings.TrimSpace(m.Content.Text) != "" {
+		parts = append(parts, m.Content.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// externalID reads the id of a chat that can't be decoded so it can be named in the report
+func externalID(entry json.RawMessage) string {
+	var chat struct {
+		ID any `json:"id"`
+	}
+	if json.Unmarshal(entry, &chat) != nil || chat.ID == nil {
+		return ""
+	}
+	return fmt.Sprint(chat.ID)
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// unixTime converts the fractional Unix seconds of ChatGPT exports
+func unixTime(seconds *float64) time.Time {
+	if seconds == nil || *seconds <= 0 {
+		return time.Time{}
+	}
+	// Postgres keeps microseconds, rounding avoids float artifacts like .122999
+	return time.UnixMicro(int64(math.Round(*seconds * 1e6))).UTC()
+}
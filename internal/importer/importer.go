@@ -0,0 +1,279 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// maxTitleLength is the maximum number of characters of imported chat titles
+const maxTitleLength = 80
+
+// Sender types as stored in the messages table
+const (
+	senderTypeUser    = "user"
+	senderTypeBackend = "backend"
+	senderTypeLLM     = "llm"
+)
+
+// senderTypes maps the roles used by chat services onto the sender types of this service
+var senderTypes = map[string]string{
+	"user":      senderTypeUser,
+	"human":     senderTypeUser,
+	"llm":       senderTypeLLM,
+	"assistant": senderTypeLLM,
+	"ai":        senderTypeLLM,
+	"bot":       senderTypeLLM,
+	"model":     senderTypeLLM,
+	"backend":   senderTypeBackend,
+	"system":    senderTypeBackend,
+	"tool":      senderTypeBackend,
+	"function":  senderTypeBackend,
+}
+
+// Status is the outcome of importing a chat
+type Status string
+
+const (
+	// StatusImported is reported for chats that were imported
+	StatusImported Status = "imported"
+	// StatusSkipped is reported for chats that were imported before
+	StatusSkipped Status = "skipped"
+	// StatusFailed is reported for chats that are invalid or could not be stored
+	StatusFailed Status = "failed"
+)
+
+// Result reports the import of a chat. ChatID is the imported chat or the chat imported before.
+type Result struct {
+	ExternalID string
+	Title      string
+	UserEmail  string
+	Status     Status
+	ChatID     uuid.UUID
+	Messages   int
+	Error      string
+}
+
+// Importer stores the chats of archives from other chat services. Every chat is imported in its own
+// transaction, so an invalid chat doesn't keep the others of the archive from being imported.
+type Importer struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewImporter creates the importer
+func NewImporter(db *sql.DB, queries *database.Queries) *Importer {
+	return &Importer{db: db, queries: queries}
+}
+
+// Import stores the chats of the archive and reports the result of every chat. The chats belong to
+// userEmail if given, otherwise to the user named in the archive.
+func (i *Importer) Import(ctx context.Context, archive Archive, userEmail string) []Result {
+	results := make([]Result, 0, len(archive.Chats))
+	for _, chat := range archive.Chats {
+		if userEmail != "" {
+			chat.UserEmail = userEmail
+		}
+		results = append(results, i.importChat(ctx, archive.Format, chat))
+	}
+	return results
+}
+
+func (i *Importer) importChat(ctx context.Context, source Format, chat Chat) Result {
+	result := Result{ExternalID: chat.ExternalID, Title: chat.Title, UserEmail: chat.UserEmail}
+	chat, err := validate(chat)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.Title = chat.Title
+	result.Messages = len(chat.Messages)
+
+	imported := database.GetImportedChatIDParams{UserEmail: chat.UserEmail, Source: string(source), ExternalID: chat.ExternalID}
+	existing, err := i.queries.GetImportedChatID(ctx, imported)
+	if err == nil {
+		result.Status = StatusSkipped
+		result.ChatID = existing
+		return result
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		result.Status = StatusFailed
+		result.Error = "failed to check for an earlier import"
+		return result
+	}
+
+	chatID, err := i.store(ctx, source, chat)
+	switch {
+	case errors.Is(err, errImportedConcurrently):
+		existing, _ := i.queries.GetImportedChatID(ctx, imported)
+		result.Status = StatusSkipped
+		result.ChatID = existing
+	case err != nil:
+		result.Status = StatusFailed
+		result.Error = "failed to store the chat"
+	default:
+		result.Status = StatusImported
+		result.ChatID = chatID
+	}
+	return result
+}
+
+// errImportedConcurrently is returned by store if another import stored the chat first
+var errImportedConcurrently = errors.New("chat imported concurrently")
+
+// store creates the chat with its messages. The messages form a single branch, backend messages
+// after the first message are attached to the message before them like tool call records.
+func (i *Importer) store(ctx context.Context, source Format, chat Chat) (uuid.UUID, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+	qtx := i.queries.WithTx(tx)
+
+	// Archives don't know tenants, the chat falls under the retention period of the tenant its user last used
+	tenant, err := qtx.GetUserTenant(ctx, chat.UserEmail)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now().UTC()
+	created, err := qtx.CreateChat(ctx, database.CreateChatParams{
+		ID:             uuid.New(),
+		Title:          chat.Title,
+		UserEmail:      chat.UserEmail,
+		Tenant:         tenant,
+		LastActiveDate: chat.LastActiveDate,
+		CreatedAt:      chat.CreatedAt,
+		UpdatedAt:      now,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var parentID uuid.NullUUID
+	for _, message := range chat.Messages {
+		stored, err := qtx.CreateMessage(ctx, database.CreateMessageParams{
+			ID:         uuid.New(),
+			Content:    message.Content,
+			SenderType: message.Role,
+			ChatID:     created.ID,
+			CreatedAt:  message.CreatedAt,
+			UpdatedAt:  now,
+			ParentID:   parentID,
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if message.Role != senderTypeBackend || !parentID.Valid {
+			parentID = uuid.NullUUID{UUID: stored.ID, Valid: true}
+		}
+	}
+
+	recorded, err := qtx.CreateChatImport(ctx, database.CreateChatImportParams{
+		UserEmail:  chat.UserEmail,
+		Source:     string(source),
+		ExternalID: chat.ExternalID,
+		ChatID:     created.ID,
+		ImportedAt: now,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if recorded == 0 {
+		return uuid.Nil, errImportedConcurrently
+	}
+	return created.ID, tx.Commit()
+}
+
+// validate checks the chat and returns it with the sender types of this service and missing dates filled in:
+// messages without date follow the message before, the chat starts with its first message and was last active
+// at its last one. Messages repeating the id of an earlier message are dropped.
+func validate(chat Chat) (Chat, error) {
+	if chat.Err != nil {
+		return chat, chat.Err
+	}
+	if chat.ExternalID == "" {
+		return chat, errors.New("the chat has no id")
+	}
+	if chat.UserEmail == "" {
+		return chat, errors.New("the chat has no user, pass the user to import it for")
+	}
+
+	last := chat.CreatedAt
+	if last.IsZero() {
+		last = firstDate(chat.Messages)
+	}
+	var messages []Message
+	seen := make(map[string]bool)
+	for n, message := range chat.Messages {
+		if message.ExternalID != "" {
+			if seen[message.ExternalID] {
+				continue
+			}
+			seen[message.ExternalID] = true
+		}
+		senderType, ok := senderTypes[strings.ToLower(message.Role)]
+		if !ok {
+			return chat, fmt.Errorf("message %d has the unknown role %q", n+1, message.Role)
+		}
+		if strings.TrimSpace(message.Content) == "" {
+			return chat, fmt.Errorf("message %d has no content", n+1)
+		}
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = last.Add(time.Microsecond)
+		}
+		message.Role = senderType
+		last = message.CreatedAt
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return chat, errors.New("the chat has no messages")
+	}
+
+	chat.Messages = messages
+	chat.Title = title(chat)
+	if chat.CreatedAt.IsZero() {
+		chat.CreatedAt = messages[0].CreatedAt
+	}
+	if chat.LastActiveDate.IsZero() {
+		chat.LastActiveDate = messages[len(messages)-1].CreatedAt
+	}
+	return chat, nil
+}
+
+// firstDate returns the date of the first message that has one, chats without any dates start now
+func firstDate(messages []Message) time.Time {
+	for _, message := range messages {
+		if !message.CreatedAt.IsZero() {
+			return message.CreatedAt
+		}
+	}
+	return time.Now().UTC()
+}
+
+// title returns the title of the chat, chats without one are titled with their first user message
+func title(chat Chat) string {
+	text := chat.Title
+	if strings.TrimSpace(text) == "" {
+		for _, message := range chat.Messages {
+			if message.Role == senderTypeUser {
+				text = message.Content
+				break
+			}
+		}
+	}
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) > maxTitleLength {
+		return string(runes[:maxTitleLength-1]) + "…"
+	}
+	return string(runes)
+}
@@ -0,0 +1,85 @@
+package knowledge
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// paragraphSeparator splits text at blank lines
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
+// chunkText splits text into chunks of at most size characters along paragraph boundaries.
+// Consecutive chunks share up to overlap characters so that context isn't lost at the boundaries.
+func chunkText(text string, size, overlap int) []string {
+	var paragraphs []string
+	for _, paragraph := range paragraphSeparator.Split(text, -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		paragraphs = append(paragraphs, splitLong(paragraph, size)...)
+	}
+
+	var chunks []string
+	var current []string
+	length := 0
+	for _, paragraph := range paragraphs {
+		paragraphLength := utf8.RuneCountInString(paragraph)
+		if length > 0 && length+paragraphLength > size {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+			current, length = overlapTail(current, overlap)
+			if length+paragraphLength > size {
+				current, length = nil, 0
+			}
+		}
+		current = append(current, paragraph)
+		length += paragraphLength
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+	}
+	return chunks
+}
+
+// overlapTail returns the trailing paragraphs of a chunk that fit into the overlap
+func overlapTail(paragraphs []string, overlap int) ([]string, int) {
+	length, start := 0, len(paragraphs)
+	for start > 0 {
+		paragraphLength := utf8.RuneCountInString(paragraphs[start-1])
+		if length+paragraphLength > overlap {
+			break
+		}
+		length += paragraphLength
+		start--
+	}
+	// The overlap must never keep the whole chunk, otherwise chunking doesn't advance
+	if start == 0 {
+		return nil, 0
+	}
+	return append([]string(nil), paragraphs[start:]...), length
+}
+
+// splitLong splits a paragraph longer than size at word boundaries
+func splitLong(paragraph string, size int) []string {
+	if utf8.RuneCountInString(paragraph) <= size {
+		return []string{paragraph}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for _, word := range strings.Fields(paragraph) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+1+utf8.RuneCountInString(word) > size {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString(" ")
+		}
+		current.WriteString(word)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
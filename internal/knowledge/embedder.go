@@ -0,0 +1,172 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"ai-chat-service-go/internal/config"
+)
+
+// mockDimensions is the size of the vectors of the mock embedder
+const mockDimensions = 256
+
+// Embedder turns texts into vectors whose cosine similarity reflects how related the texts are
+type Embedder interface {
+	// Model identifies the embedding model, vectors of different models can't be compared
+	Model() string
+	// Embed returns one vector per text
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder creates the embedding provider selected in the configuration
+func NewEmbedder(cfg config.KnowledgeConfig) (Embedder, error) {
+	switch cfg.EmbeddingProvider {
+	case "", "mock":
+		return MockEmbedder{}, nil
+	case "openai":
+		return NewOpenAIEmbedder(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", cfg.EmbeddingProvider)
+	}
+}
+
+// MockEmbedder hashes the words of a text into a fixed number of buckets.
+// It only captures shared words, which is good enough for local development.
+type MockEmbedder struct{}
+
+func (e MockEmbedder) Model() string {
+	return "mock"
+}
+
+func (e MockEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector := make([]float32, mockDimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			hash := fnv.New32a()
+			hash.Write([]byte(word))
+			vector[hash.Sum32()%mockDimensions]++
+		}
+		vectors = append(vectors, normalize(vector))
+	}
+	return vectors, nil
+}
+
+// OpenAIEmbedder uses the embeddings endpoint of the OpenAI API or a compatible server
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIEmbedder creates an embedder for the OpenAI compatible API in the configuration
+func NewOpenAIEmbedder(cfg config.KnowledgeConfig) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimSuffix(cfg.EmbeddingBaseURL, "/"),
+		apiKey:  cfg.EmbeddingAPIKey,
+		model:   cfg.EmbeddingModel,
+		client:  &http.Client{Timeout: cfg.EmbeddingTimeout},
+	}
+}
+
+func (e *OpenAIEmbedder) Model() string {
+	return e.model
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding embeddings: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(result.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// normalize scales a vector to unit length, so the dot product is the cosine similarity
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	length := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= length
+	}
+	return vector
+}
+
+// cosine returns the cosine similarity of two vectors of the same length
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,141 @@
+package knowledge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Format is the format of an ingested document
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatPDF      Format = "pdf"
+	FormatText     Format = "text"
+)
+
+// ErrUnsupportedFormat is returned for documents that can't be ingested
+var ErrUnsupportedFormat = fmt.Errorf("unsupported document format")
+
+// FormatFromFilename derives the format from the file extension
+func FormatFromFilename(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	case ".pdf":
+		return FormatPDF, nil
+	case ".txt":
+		return FormatText, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, name)
+	}
+}
+
+// markdownHeading matches the first level one heading of a Markdown document
+var markdownHeading = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+
+// extract returns the plain text of a document and its title, if the document has one
+func extract(format Format, data []byte) (text, title string, err error) {
+	switch format {
+	case FormatMarkdown:
+		text = string(data)
+		if match := markdownHeading.FindStringSubmatch(text); match != nil {
+			title = strings.TrimSpace(match[1])
+		}
+		return text, title, nil
+	case FormatHTML:
+		return extractHTML(data)
+	case FormatPDF:
+		text, err = extractPDF(data)
+		return text, "", err
+	case FormatText:
+		return string(data), "", nil
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// extractHTML returns the visible text of an HTML document, block elements become paragraphs
+func extractHTML(data []byte) (string, string, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", "", err
+	}
+
+	var text strings.Builder
+	var title string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.ElementNode:
+			switch node.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template:
+				return
+			case atom.Title:
+				if node.FirstChild != nil && title == "" {
+					title = strings.TrimSpace(node.FirstChild.Data)
+				}
+				return
+			}
+		case html.TextNode:
+			if content := strings.Join(strings.Fields(node.Data), " "); content != "" {
+				text.WriteString(content)
+				text.WriteString(" ")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode && isBlock(node.DataAtom) {
+			text.WriteString("\n\n")
+		}
+	}
+	walk(root)
+
+	return text.String(), title, nil
+}
+
+// isBlock reports whether an element ends a paragraph of text
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Li, atom.Tr, atom.Br, atom.Pre, atom.Table,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Blockquote:
+		return true
+	}
+	return false
+}
+
+// extractPDF returns the text layer of a PDF document
+func extractPDF(data []byte) (text string, err error) {
+	// The PDF reader panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("reading PDF: %w", err)
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("reading PDF text: %w", err)
+	}
+	content, err := io.ReadAll(plain)
+	if err != nil {
+		return "", fmt.Errorf("reading PDF text: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,186 @@
+package knowledge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the progress of an ingestion job: queued -> chunking -> embedding -> done or failed
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobChunking  JobStatus = "chunking"
+	JobEmbedding JobStatus = "embedding"
+	JobDone      JobStatus = "done"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobKind is what an ingestion job does
+type JobKind string
+
+const (
+	// JobUpload ingests a new version of a document
+	JobUpload JobKind = "upload"
+	// JobReindex chunks and embeds the stored text of a document again
+	JobReindex JobKind = "reindex"
+)
+
+// staleJobTimeout is how long an unfinished job may go without progress before it is considered lost.
+// Jobs are only queued in memory, so they don't survive a restart.
+const staleJobTimeout = 15 * time.Minute
+
+// ErrQueueFull is returned when too many documents are waiting for ingestion
+var ErrQueueFull = errors.New("ingestion queue is full")
+
+// task is a queued job, uploads carry the document until it is stored
+type task struct {
+	job    database.KbIngestionJob
+	format Format
+	data   []byte
+}
+
+// Upload queues the ingestion of an uploaded document. A document with the same source is replaced.
+func (b *Base) Upload(ctx context.Context, source string, format Format, data []byte) (database.KbIngestionJob, error) {
+	job, err := b.createJob(ctx, uuid.NullUUID{}, source, JobUpload, JobQueued)
+	if err != nil {
+		return database.KbIngestionJob{}, err
+	}
+	return b.enqueue(ctx, task{job: job, format: format, data: data})
+}
+
+// Reindex queues chunking and embedding the stored text of a document again
+func (b *Base) Reindex(ctx context.Context, documentID uuid.UUID) (database.KbIngestionJob, error) {
+	document, err := b.queries.GetKnowledgeDocument(ctx, documentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.KbIngestionJob{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return database.KbIngestionJob{}, err
+	}
+
+	job, err := b.createJob(ctx, uuid.NullUUID{UUID: document.ID, Valid: true}, document.Source, JobReindex, JobQueued)
+	if err != nil {
+		return database.KbIngestionJob{}, err
+	}
+	return b.enqueue(ctx, task{job: job})
+}
+
+// enqueue hands the task to the workers, the job fails right away if the queue is full
+func (b *Base) enqueue(ctx context.Context, t task) (database.KbIngestionJob, error) {
+	select {
+	case b.queue <- t:
+		return t.job, nil
+	default:
+		b.finish(ctx, &t.job, ErrQueueFull)
+		return t.job, ErrQueueFull
+	}
+}
+
+// work runs queued jobs until the knowledge base is closed
+func (b *Base) work(ctx context.Context, ready <-chan struct{}) {
+	defer b.workers.Done()
+	select {
+	case <-ready:
+	case <-b.stop:
+		return
+	}
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case t := <-b.queue:
+			b.run(ctx, t)
+		}
+	}
+}
+
+// run executes a job and records its outcome
+func (b *Base) run(ctx context.Context, t task) {
+	job := t.job
+	b.update(ctx, &job, JobChunking)
+
+	var err error
+	switch JobKind(job.Kind) {
+	case JobUpload:
+		var document parsed
+		document, err = parse(job.Source, t.format, t.data)
+		if err == nil {
+			err = b.ingest(ctx, &job, document)
+		}
+	case JobReindex:
+		err = b.reindex(ctx, &job)
+	}
+	b.finish(ctx, &job, err)
+}
+
+// createJob records a new ingestion job
+func (b *Base) createJob(ctx context.Context, documentID uuid.NullUUID, source string, kind JobKind, status JobStatus) (database.KbIngestionJob, error) {
+	now := time.Now().UTC()
+	return b.queries.CreateKnowledgeJob(ctx, database.CreateKnowledgeJobParams{
+		ID:         uuid.New(),
+		DocumentID: documentID,
+		Source:     source,
+		Kind:       string(kind),
+		Status:     string(status),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
+
+// update persists the status and progress of the job. The job itself matters more
+// than its bookkeeping, so failures are only logged.
+func (b *Base) update(ctx context.Context, job *database.KbIngestionJob, status JobStatus) {
+	job.Status = string(status)
+	updated, err := b.queries.UpdateKnowledgeJob(ctx, database.UpdateKnowledgeJobParams{
+		ID:             job.ID,
+		DocumentID:     job.DocumentID,
+		Status:         job.Status,
+		Error:          job.Error,
+		Chunks:         job.Chunks,
+		EmbeddedChunks: job.EmbeddedChunks,
+		UpdatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Failed to update ingestion job %s: %v", job.ID, err)
+		return
+	}
+	*job = updated
+}
+
+// finish marks the job as done, or as failed with the error
+func (b *Base) finish(ctx context.Context, job *database.KbIngestionJob, err error) {
+	if err != nil {
+		job.Error = err.Error()
+		b.update(ctx, job, JobFailed)
+		log.Printf("Failed to ingest %s: %v", job.Source, err)
+		return
+	}
+	b.update(ctx, job, JobDone)
+	log.Printf("Ingested %s into the knowledge base (%d chunks)", job.Source, job.Chunks)
+}
+
+// failStaleJobs fails the jobs that were lost in a restart of this or another instance
+func (b *Base) failStaleJobs(ctx context.Context) {
+	now := time.Now().UTC()
+	failed, err := b.queries.FailStaleKnowledgeJobs(ctx, database.FailStaleKnowledgeJobsParams{
+		Error:       "interrupted by a restart",
+		UpdatedAt:   now,
+		StaleBefore: now.Add(-staleJobTimeout),
+	})
+	if err != nil {
+		log.Printf("Failed to clean up stale ingestion jobs: %v", err)
+		return
+	}
+	if failed > 0 {
+		log.Printf("Marked %d interrupted ingestion jobs as failed", failed)
+	}
+}
@@ -0,0 +1,424 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// embeddingBatchSize is the number of chunks embedded per request to the embedding provider
+const embeddingBatchSize = 64
+
+var (
+	// ErrEmptyDocument is returned for documents without any text
+	ErrEmptyDocument = errors.New("document contains no text")
+	// ErrDocumentNotFound is returned for documents that don't exist
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
+// Base is the knowledge base the LLM answers from. Documents and their chunks are stored
+// in Postgres, the embedded chunks in the vector store. Uploaded documents are ingested
+// in the background by a pool of workers, the progress is tracked as ingestion jobs.
+type Base struct {
+	cfg      config.KnowledgeConfig
+	db       *sql.DB
+	queries  *database.Queries
+	embedder Embedder
+	vectors  VectorStore
+	queue    chan task
+	stop     chan struct{}
+	workers  sync.WaitGroup
+}
+
+// NewBase creates the knowledge base
+func NewBase(cfg config.KnowledgeConfig, db *sql.DB, queries *database.Queries, embedder Embedder, vectors VectorStore) *Base {
+	return &Base{
+		cfg:      cfg,
+		db:       db,
+		queries:  queries,
+		embedder: embedder,
+		vectors:  vectors,
+		queue:    make(chan task, max(cfg.IngestionQueueSize, 1)),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start starts the ingestion workers. In the background it fills an in-memory vector store
+// from the stored chunks and ingests the documents of the knowledge directory.
+// Uploads are processed once the vector store has been filled.
+func (b *Base) Start(ctx context.Context) {
+	ready := make(chan struct{})
+	for range max(b.cfg.IngestionWorkers, 1) {
+		b.workers.Add(1)
+		go b.work(ctx, ready)
+	}
+
+	go func() {
+		b.failStaleJobs(ctx)
+		if !b.vectors.Persistent() {
+			if err := b.rebuild(ctx); err != nil {
+				log.Printf("Failed to rebuild the knowledge base vectors: %v", err)
+			}
+		}
+		close(ready)
+		if err := b.IngestDir(ctx); err != nil {
+			log.Printf("Failed to ingest the knowledge directory: %v", err)
+		}
+	}()
+}
+
+// Close stops the workers after their current job, queued jobs are dropped
+func (b *Base) Close(ctx context.Context) error {
+	close(b.stop)
+	done := make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IngestDir ingests every supported document of the knowledge directory, unchanged documents are skipped
+func (b *Base) IngestDir(ctx context.Context) error {
+	if b.cfg.Dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(b.cfg.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		format, err := FormatFromFilename(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(b.cfg.Dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		document, err := parse(entry.Name(), format, data)
+		if err != nil {
+			log.Printf("Failed to ingest %s: %v", entry.Name(), err)
+			continue
+		}
+		if _, unchanged, err := b.current(ctx, document); err != nil {
+			return err
+		} else if unchanged {
+			continue
+		}
+
+		job, err := b.createJob(ctx, uuid.NullUUID{}, entry.Name(), JobUpload, JobChunking)
+		if err != nil {
+			return err
+		}
+		b.finish(ctx, &job, b.ingest(ctx, &job, document))
+	}
+	return nil
+}
+
+// parsed is the text extracted from a document
+type parsed struct {
+	source      string
+	title       string
+	format      Format
+	text        string
+	contentHash string
+}
+
+// parse extracts the text of a document, the title falls back to the file name
+func parse(source string, format Format, data []byte) (parsed, error) {
+	text, title, err := extract(format, data)
+	if err != nil {
+		return parsed{}, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return parsed{}, ErrEmptyDocument
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	hash := sha256.Sum256([]byte(text))
+	return parsed{
+		source:      source,
+		title:       title,
+		format:      format,
+		text:        text,
+		contentHash: hex.EncodeToString(hash[:]),
+	}, nil
+}
+
+// current reports whether the document is stored with the same text and its latest ingestion completed
+func (b *Base) current(ctx context.Context, document parsed) (database.KbDocument, bool, error) {
+	existing, err := b.queries.GetKnowledgeDocumentBySource(ctx, document.source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.KbDocument{}, false, nil
+	}
+	if err != nil {
+		return database.KbDocument{}, false, err
+	}
+	if existing.ContentHash != document.contentHash {
+		return existing, false, nil
+	}
+
+	latest, err := b.queries.GetLatestKnowledgeJobByDocumentID(ctx, uuid.NullUUID{UUID: existing.ID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Documents ingested before jobs were tracked
+		return existing, true, nil
+	}
+	if err != nil {
+		return database.KbDocument{}, false, err
+	}
+	return existing, latest.Status == string(JobDone), nil
+}
+
+// ingest stores, chunks and embeds a document. A document with the same source is replaced,
+// unless its content didn't change.
+func (b *Base) ingest(ctx context.Context, job *database.KbIngestionJob, document parsed) error {
+	existing, unchanged, err := b.current(ctx, document)
+	if err != nil {
+		return err
+	}
+	if unchanged {
+		count, err := b.queries.CountKnowledgeChunks(ctx, existing.ID)
+		if err != nil {
+			return err
+		}
+		job.DocumentID = uuid.NullUUID{UUID: existing.ID, Valid: true}
+		job.Chunks, job.EmbeddedChunks = int32(count), int32(count)
+		return nil
+	}
+
+	stored, chunks, err := b.store(ctx, document)
+	if err != nil {
+		return err
+	}
+	job.DocumentID = uuid.NullUUID{UUID: stored.ID, Valid: true}
+	job.Chunks = int32(len(chunks))
+	return b.embed(ctx, job, stored, chunks)
+}
+
+// reindex chunks and embeds the stored text of the document of the job again
+func (b *Base) reindex(ctx context.Context, job *database.KbIngestionJob) error {
+	document, err := b.queries.GetKnowledgeDocument(ctx, job.DocumentID.UUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDocumentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	chunks, err := b.storeChunks(ctx, b.queries.WithTx(tx), document)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	job.Chunks = int32(len(chunks))
+	return b.embed(ctx, job, document, chunks)
+}
+
+// store replaces the document and its chunks in the database
+func (b *Base) store(ctx context.Context, document parsed) (database.KbDocument, []database.KbChunk, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.KbDocument{}, nil, err
+	}
+	defer tx.Rollback()
+	qtx := b.queries.WithTx(tx)
+
+	now := time.Now().UTC()
+	stored, err := qtx.UpsertKnowledgeDocument(ctx, database.UpsertKnowledgeDocumentParams{
+		ID:          uuid.New(),
+		Source:      document.source,
+		Title:       document.title,
+		Format:      string(document.format),
+		Content:     document.text,
+		ContentHash: document.contentHash,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return database.KbDocument{}, nil, err
+	}
+	chunks, err := b.storeChunks(ctx, qtx, stored)
+	if err != nil {
+		return database.KbDocument{}, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.KbDocument{}, nil, err
+	}
+	return stored, chunks, nil
+}
+
+// storeChunks replaces the chunks of the document with chunks of its current text
+func (b *Base) storeChunks(ctx context.Context, qtx *database.Queries, document database.KbDocument) ([]database.KbChunk, error) {
+	if err := qtx.DeleteKnowledgeChunksByDocumentID(ctx, document.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var chunks []database.KbChunk
+	for i, content := range chunkText(document.Content, b.cfg.ChunkSize, b.cfg.ChunkOverlap) {
+		stored, err := qtx.CreateKnowledgeChunk(ctx, database.CreateKnowledgeChunkParams{
+			ID:         uuid.New(),
+			DocumentID: document.ID,
+			Position:   int32(i),
+			Content:    content,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, stored)
+	}
+	return chunks, nil
+}
+
+// embed embeds the chunks of a document and replaces its entries in the vector store
+func (b *Base) embed(ctx context.Context, job *database.KbIngestionJob, document database.KbDocument, chunks []database.KbChunk) error {
+	b.update(ctx, job, JobEmbedding)
+
+	entries := make([]Entry, 0, len(chunks))
+	for _, chunk := range chunks {
+		entries = append(entries, Entry{
+			ChunkID:    chunk.ID,
+			DocumentID: document.ID,
+			Title:      document.Title,
+			Content:    chunk.Content,
+		})
+	}
+	err := b.embedEntries(ctx, entries, func(embedded int) {
+		job.EmbeddedChunks = int32(embedded)
+		b.update(ctx, job, JobEmbedding)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := b.vectors.DeleteDocument(ctx, document.ID); err != nil {
+		return err
+	}
+	return b.vectors.Put(ctx, b.embedder.Model(), entries)
+}
+
+// DeleteDocument removes a document with its chunks and vectors
+func (b *Base) DeleteDocument(ctx context.Context, documentID uuid.UUID) error {
+	deleted, err := b.queries.DeleteKnowledgeDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrDocumentNotFound
+	}
+	return b.vectors.DeleteDocument(ctx, documentID)
+}
+
+// embedEntries sets the vectors of the entries in batches, progress is called with the number of embedded entries
+func (b *Base) embedEntries(ctx context.Context, entries []Entry, progress func(embedded int)) error {
+	for start := 0; start < len(entries); start += embeddingBatchSize {
+		batch := entries[start:min(start+embeddingBatchSize, len(entries))]
+		texts := make([]string, 0, len(batch))
+		for _, entry := range batch {
+			texts = append(texts, entry.Title+"\n\n"+entry.Content)
+		}
+		vectors, err := b.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("embedding with %s: %w", b.embedder.Model(), err)
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+		if progress != nil {
+			progress(start + len(batch))
+		}
+	}
+	return nil
+}
+
+// rebuild embeds all stored chunks into the vector store
+func (b *Base) rebuild(ctx context.Context) error {
+	chunks, err := b.queries.ListKnowledgeChunks(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, 0, len(chunks))
+	for _, chunk := range chunks {
+		entries = append(entries, Entry{
+			ChunkID:    chunk.ID,
+			DocumentID: chunk.DocumentID,
+			Title:      chunk.Title,
+			Content:    chunk.Content,
+		})
+	}
+	if err := b.embedEntries(ctx, entries, nil); err != nil {
+		return err
+	}
+	return b.vectors.Put(ctx, b.embedder.Model(), entries)
+}
+
+// Retrieve returns the chunks most relevant to the query, at most TopK with a score of at least MinScore
+func (b *Base) Retrieve(ctx context.Context, query string) ([]Match, error) {
+	vectors, err := b.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embedding with %s: %w", b.embedder.Model(), err)
+	}
+	matches, err := b.vectors.Search(ctx, b.embedder.Model(), vectors[0], b.cfg.TopK)
+	if err != nil {
+		return nil, err
+	}
+
+	relevant := matches[:0]
+	for _, match := range matches {
+		if match.Score >= b.cfg.MinScore {
+			relevant = append(relevant, match)
+		}
+	}
+	return relevant, nil
+}
+
+// Prompt formats the matches as instructions for the LLM, sources are cited by their number
+func Prompt(matches []Match) string {
+	var prompt strings.Builder
+	prompt.WriteString("Answer using the following excerpts from the device documentation. ")
+	prompt.WriteString("Cite the excerpts you used by their number, e.g. [1]. ")
+	prompt.WriteString("If the excerpts don't answer the question, say so instead of guessing.\n")
+	for i, match := range matches {
+		fmt.Fprintf(&prompt, "\n[%d] %s\n%s\n", i+1, match.Title, match.Content)
+	}
+	return prompt.String()
+}
@@ -0,0 +1,198 @@
+package knowledge
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Entry is an embedded chunk of a document
+type Entry struct {
+	ChunkID    uuid.UUID
+	DocumentID uuid.UUID
+	Title      string
+	Content    string
+	Vector     []float32
+}
+
+// Match is a chunk found for a query, Score is the cosine similarity
+type Match struct {
+	ChunkID    uuid.UUID
+	DocumentID uuid.UUID
+	Title      string
+	Content    string
+	Score      float64
+}
+
+// VectorStore keeps the embedded chunks and finds the ones closest to a query
+type VectorStore interface {
+	// Put adds the entries, entries embedded with another model are never returned together
+	Put(ctx context.Context, model string, entries []Entry) error
+	// DeleteDocument removes all entries of a document
+	DeleteDocument(ctx context.Context, documentID uuid.UUID) error
+	// Search returns the k entries most similar to the vector
+	Search(ctx context.Context, model string, vector []float32, k int) ([]Match, error)
+	// Persistent reports whether entries survive a restart
+	Persistent() bool
+}
+
+// NewVectorStore creates the store selected in the configuration. If pgvector isn't
+// available in the database the in-memory store is used instead.
+func NewVectorStore(ctx context.Context, name string, db *sql.DB) (VectorStore, error) {
+	switch name {
+	case "", "memory":
+		return NewMemoryVectorStore(), nil
+	case "postgres":
+		var table sql.NullString
+		if err := db.QueryRowContext(ctx, "SELECT to_regclass('kb_embeddings')::text").Scan(&table); err != nil {
+			log.Printf("Failed to check for pgvector, falling back to the in-memory vector store: %v", err)
+			return NewMemoryVectorStore(), nil
+		}
+		if !table.Valid {
+			log.Printf("The pgvector extension is not installed, falling back to the in-memory vector store")
+			return NewMemoryVectorStore(), nil
+		}
+		return NewPgVectorStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown vector store %q", name)
+	}
+}
+
+// MemoryVectorStore keeps the entries in process memory and searches them exhaustively.
+// Entries are lost on restart and rebuilt from the chunks in the database.
+type MemoryVectorStore struct {
+	mu      sync.RWMutex
+	entries map[uuid.UUID]memoryEntry
+}
+
+type memoryEntry struct {
+	Entry
+	model string
+}
+
+// NewMemoryVectorStore creates an empty in-memory store
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{entries: make(map[uuid.UUID]memoryEntry)}
+}
+
+func (s *MemoryVectorStore) Put(ctx context.Context, model string, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.entries[entry.ChunkID] = memoryEntry{Entry: entry, model: model}
+	}
+	return nil
+}
+
+func (s *MemoryVectorStore) DeleteDocument(ctx context.Context, documentID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, entry := range s.entries {
+		if entry.DocumentID == documentID {
+			delete(s.entries, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryVectorStore) Search(ctx context.Context, model string, vector []float32, k int) ([]Match, error) {
+	s.mu.RLock()
+	matches := make([]Match, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if entry.model != model {
+			continue
+		}
+		matches = append(matches, Match{
+			ChunkID:    entry.ChunkID,
+			DocumentID: entry.DocumentID,
+			Title:      entry.Title,
+			Content:    entry.Content,
+			Score:      cosine(vector, entry.Vector),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (s *MemoryVectorStore) Persistent() bool {
+	return false
+}
+
+// PgVectorStore keeps the vectors in the kb_embeddings table.
+// The table only exists if pgvector is installed, so its queries are not part of the sqlc queries.
+type PgVectorStore struct {
+	db *sql.DB
+}
+
+// NewPgVectorStore creates a store backed by pgvector
+func NewPgVectorStore(db *sql.DB) *PgVectorStore {
+	return &PgVectorStore{db: db}
+}
+
+const putEmbedding = `
+INSERT INTO kb_embeddings (chunk_id, model, embedding)
+VALUES ($1, $2, $3)
+ON CONFLICT (chunk_id) DO UPDATE SET model = EXCLUDED.model, embedding = EXCLUDED.embedding
+`
+
+func (s *PgVectorStore) Put(ctx context.Context, model string, entries []Entry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(ctx, putEmbedding, entry.ChunkID, model, pgvector.NewVector(entry.Vector)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteDocument is a no-op, embeddings are deleted along with the chunks of the document
+func (s *PgVectorStore) DeleteDocument(ctx context.Context, documentID uuid.UUID) error {
+	return nil
+}
+
+const searchEmbeddings = `
+SELECT c.id, c.document_id, d.title, c.content, 1 - (e.embedding <=> $2::vector) AS score
+FROM kb_embeddings e
+JOIN kb_chunks c ON c.id = e.chunk_id
+JOIN kb_documents d ON d.id = c.document_id
+WHERE e.model = $1
+ORDER BY e.embedding <=> $2::vector
+LIMIT $3
+`
+
+func (s *PgVectorStore) Search(ctx context.Context, model string, vector []float32, k int) ([]Match, error) {
+	rows, err := s.db.QueryContext(ctx, searchEmbeddings, model, pgvector.NewVector(vector), k)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var match Match
+		if err := rows.Scan(&match.ChunkID, &match.DocumentID, &match.Title, &match.Content, &match.Score); err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+func (s *PgVectorStore) Persistent() bool {
+	return true
+}
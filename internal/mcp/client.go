@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+)
+
+// clientInfo identifies the service to the servers
+var clientInfo = implementation{Name: "ai-chat-service-go", Version: "1.0.0"}
+
+// Client is a connection to an MCP server whose tools were discovered on connect
+type Client struct {
+	name      string
+	transport transport
+	nextID    atomic.Int64
+	tools     []Tool
+}
+
+// Connect starts or connects to the server, performs the initialization handshake and lists its tools
+func Connect(ctx context.Context, name string, server ServerConfig) (*Client, error) {
+	var t transport
+	if server.Command != "" {
+		stdio, err := newStdioTransport(name, server)
+		if err != nil {
+			return nil, err
+		}
+		t = stdio
+	} else {
+		t = newHTTPTransport(server)
+	}
+
+	c := &Client{name: name, transport: t}
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("initializing MCP server %s: %w", name, err)
+	}
+	tools, err := c.listTools(ctx)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("listing tools of MCP server %s: %w", name, err)
+	}
+	c.tools = tools
+	return c, nil
+}
+
+// ConnectAll connects to the configured servers. Servers that can't be reached are logged and skipped,
+// so an unavailable server doesn't prevent the service from starting.
+func ConnectAll(ctx context.Context, cfg Config) []*Client {
+	clients := make([]*Client, 0, len(cfg.Servers))
+	for name, server := range cfg.Servers {
+		client, err := Connect(ctx, name, server)
+		if err != nil {
+			log.Printf("Skipping MCP server: %v", err)
+			continue
+		}
+		log.Printf("Connected to MCP server %s with %d tools", name, len(client.Tools()))
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Name returns the name of the server in the configuration
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Tools returns the tools discovered on connect
+func (c *Client) Tools() []Tool {
+	return c.tools
+}
+
+// CallTool calls a tool of the server. A failing tool is not an error, it's reported in the result.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (CallToolResult, error) {
+	var result CallToolResult
+	err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: arguments}, &result)
+	return result, err
+}
+
+// Close ends the session, stdio servers are stopped
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := initializeParams{
+		ProtocolVersion: protocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      clientInfo,
+	}
+	var result initializeResult
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	return c.notify(ctx, "notifications/initialized")
+}
+
+// listTools pages through the tools of the server
+func (c *Client) listTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var result listToolsResult
+		if err := c.call(ctx, "tools/list", listToolsParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// call sends a request and decodes the result of the response
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+	response, err := c.transport.roundTrip(ctx, message{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(id),
+		Method:  method,
+		Params:  encoded,
+	})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	return c.transport.notify(ctx, message{JSONRPC: "2.0", Method: method})
+}
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fixtureBinary is the MCP server of testdata/fixture, built once for all tests
+var fixtureBinary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "mcp-fixture")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "creating temp dir: %v\n", err)
+		os.Exit(1)
+	}
+	fixtureBinary = filepath.Join(dir, "fixture")
+	build := exec.Command("go", "build", "-o", fixtureBinary, "./testdata/fixture")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "building MCP fixture: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// stdioFixture returns the configuration starting the fixture as subprocess
+func stdioFixture() ServerConfig {
+	return ServerConfig{Command: fixtureBinary}
+}
+
+// httpFixture starts the fixture serving streamable HTTP and returns the configuration to reach it
+func httpFixture(t *testing.T) ServerConfig {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding a free port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cmd := exec.Command(fixtureBinary, "-http", addr)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting MCP fixture: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	url := "http://" + addr + "/mcp"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return ServerConfig{URL: url}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("MCP fixture did not start listening on %s: %v", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func connect(t *testing.T, name string, server ServerConfig) *Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, name, server)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func toolNames(tools []Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestClient(t *testing.T) {
+	transports := []struct {
+		name   string
+		server func(t *testing.T) ServerConfig
+	}{
+		{name: "stdio", server: func(t *testing.T) ServerConfig { return stdioFixture() }},
+		{name: "http", server: httpFixture},
+	}
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			client := connect(t, "fixture", transport.server(t))
+
+			t.Run("discovers tools", func(t *testing.T) {
+				if name := client.Name(); name != "fixture" {
+					t.Errorf("Name() = %q, want fixture", name)
+				}
+				names := toolNames(client.Tools())
+				if want := []string{"echo", "add", "fail"}; !slices.Equal(names, want) {
+					t.Fatalf("tools = %v, want %v", names, want)
+				}
+				echo := client.Tools()[0]
+				if echo.Description != "Returns the given text." {
+					t.Errorf("echo description = %q", echo.Description)
+				}
+				var schema struct {
+					Required []string `json:"required"`
+				}
+				if err := json.Unmarshal(echo.InputSchema, &schema); err != nil {
+					t.Fatalf("decoding echo input schema: %v", err)
+				}
+				if !slices.Equal(schema.Required, []string{"text"}) {
+					t.Errorf("echo requires %v, want [text]", schema.Required)
+				}
+			})
+
+			tests := []struct {
+				tool      string
+				arguments string
+				want      string
+				isError   bool
+			}{
+				{tool: "echo", arguments: `{"text":"hello"}`, want: "hello"},
+				{tool: "add", arguments: `{"a":2,"b":3.5}`, want: "5.5"},
+				{tool: "fail", arguments: `{}`, want: "tool fail failed", isError: true},
+			}
+			for _, tt := range tests {
+				t.Run("calls "+tt.tool, func(t *testing.T) {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					result, err := client.CallTool(ctx, tt.tool, json.RawMessage(tt.arguments))
+					if err != nil {
+						t.Fatalf("CallTool: %v", err)
+					}
+					if text := result.Text(); text != tt.want {
+						t.Errorf("Text() = %q, want %q", text, tt.want)
+					}
+					if result.IsError != tt.isError {
+						t.Errorf("IsError = %v, want %v", result.IsError, tt.isError)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestConnectFailsForUnreachableServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := Connect(ctx, "missing", ServerConfig{Command: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("Connect to a missing command succeeded")
+	}
+}
+
+func TestConfigAllowed(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "mcp.json")
+	config := fmt.Sprintf(`{
+		"mcpServers": {
+			"local": {"command": %q},
+			"remote": {"url": %q}
+		},
+		"allowedTools": {
+			"user": ["local__echo", "remote__add"],
+			"support": ["local__*"],
+			"admin": ["*"]
+		}
+	}`, fixtureBinary, httpFixture(t).URL)
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clients := ConnectAll(ctx, cfg)
+	var qualified []string
+	for _, client := range clients {
+		defer client.Close()
+		for _, tool := range client.Tools() {
+			qualified = append(qualified, client.Name()+"__"+tool.Name)
+		}
+	}
+	slices.Sort(qualified)
+	if len(qualified) != 6 {
+		t.Fatalf("discovered tools %v, want the three tools of both servers", qualified)
+	}
+
+	tests := []struct {
+		name  string
+		roles []string
+		want  []string
+	}{
+		{name: "exact names", roles: []string{"user"}, want: []string{"local__echo", "remote__add"}},
+		{name: "pattern per server", roles: []string{"support"}, want: []string{"local__add", "local__echo", "local__fail"}},
+		{name: "roles combine", roles: []string{"user", "support"}, want: []string{"local__add", "local__echo", "local__fail", "remote__add"}},
+		{name: "wildcard", roles: []string{"admin"}, want: qualified},
+		{name: "unlisted role", roles: []string{"guest"}, want: nil},
+		{name: "no roles", roles: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var allowed []string
+			for _, tool := range qualified {
+				if cfg.Allowed(tt.roles, tool) {
+					allowed = append(allowed, tool)
+				}
+			}
+			if !slices.Equal(allowed, tt.want) {
+				t.Errorf("allowed %v, want %v", allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsInvalidServers(t *testing.T) {
+	tests := map[string]string{
+		"neither command nor URL": `{"mcpServers": {"docs": {}}}`,
+		"command and URL":         `{"mcpServers": {"docs": {"command": "docs", "url": "http://localhost/mcp"}}}`,
+		"invalid pattern":         `{"allowedTools": {"user": ["docs__["]}}`,
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "mcp.json")
+			if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadConfig(configFile); err == nil {
+				t.Error("LoadConfig succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+)
+
+// Config lists the MCP servers and which of their tools each role may use, in the JSON format
+// also used by MCP hosts: {"mcpServers": {"docs": {"command": "..."}}, "allowedTools": {"user": ["docs__*"]}}
+type Config struct {
+	Servers map[string]ServerConfig `json:"mcpServers"`
+	// AllowedTools maps a role to the tools it may use, as glob patterns of the qualified tool names.
+	// Users without a listed role can't use any MCP tool.
+	AllowedTools map[string][]string `json:"allowedTools"`
+}
+
+// ServerConfig describes how to reach a server: servers with a command are started as subprocess
+// and spoken to over stdio, servers with a URL over streamable HTTP
+type ServerConfig struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// LoadConfig reads the configuration file. References to environment variables like ${TOKEN}
+// are expanded, so secrets don't have to be stored in the file.
+func LoadConfig(filename string) (Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	for name, server := range cfg.Servers {
+		if (server.Command == "") == (server.URL == "") {
+			return Config{}, fmt.Errorf("MCP server %s needs either a command or a URL", name)
+		}
+	}
+	for role, patterns := range cfg.AllowedTools {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return Config{}, fmt.Errorf("invalid tool pattern %q for role %s: %w", pattern, role, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Allowed reports whether one of the roles may use the tool
+func (c Config) Allowed(roles []string, tool string) bool {
+	for role, patterns := range c.AllowedTools {
+		if !slices.Contains(roles, role) {
+			continue
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, tool); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// ServerTool is a tool the service offers to MCP clients. The handler output is returned as text content,
+// a handler error is reported to the client as failed tool call.
+type ServerTool struct {
+	Tool
+	Handler func(ctx context.Context, arguments json.RawMessage) (string, error)
+}
+
+// Server answers the requests of MCP clients. It is stateless: the tools are passed with every message,
+// so they can be bound to the authenticated user of the request.
+type Server struct {
+	info implementation
+}
+
+// NewServer creates a server identifying itself with the given name and version
+func NewServer(name, version string) *Server {
+	return &Server{info: implementation{Name: name, Version: version}}
+}
+
+// Handle answers a JSON-RPC message. Notifications and responses have no answer and return nil,
+// invalid JSON is answered with a parse error and ok false.
+func (s *Server) Handle(ctx context.Context, body []byte, tools []ServerTool) (response json.RawMessage, ok bool) {
+	var request message
+	if err := json.Unmarshal(body, &request); err != nil {
+		return encode(errorResponse(json.RawMessage("null"), codeParseError, "Parse error")), false
+	}
+	if request.JSONRPC != "2.0" || (request.Method == "" && len(request.ID) == 0) {
+		return encode(errorResponse(json.RawMessage("null"), codeInvalidRequest, "Invalid request")), false
+	}
+	if !request.isRequest() {
+		return nil, true
+	}
+
+	result, rpcErr := s.dispatch(ctx, request, tools)
+	if rpcErr != nil {
+		return encode(message{JSONRPC: "2.0", ID: request.ID, Error: rpcErr}), true
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return encode(errorResponse(request.ID, codeInternalError, err.Error())), true
+	}
+	return encode(message{JSONRPC: "2.0", ID: request.ID, Result: encoded}), true
+}
+
+func (s *Server) dispatch(ctx context.Context, request message, tools []ServerTool) (any, *rpcError) {
+	switch request.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		// The client's revision is accepted if known, otherwise it has to speak ours
+		version := protocolVersion
+		if slices.Contains(supportedVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      s.info,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		list := make([]Tool, 0, len(tools))
+		for _, tool := range tools {
+			list = append(list, tool.Tool)
+		}
+		return listToolsResult{Tools: list}, nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		index := slices.IndexFunc(tools, func(tool ServerTool) bool { return tool.Name == params.Name })
+		if index < 0 {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool " + params.Name}
+		}
+		arguments := params.Arguments
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+		output, err := tools[index].Handler(ctx, arguments)
+		if err != nil {
+			return CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return CallToolResult{Content: []Content{{Type: "text", Text: output}}}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + request.Method}
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, text string) message {
+	return message{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: text}}
+}
+
+func encode(msg message) json.RawMessage {
+	encoded, _ := json.Marshal(msg)
+	return encoded
+}
@@ -0,0 +1,159 @@
+// Command fixture is a tiny MCP server for trying out the MCP client locally.
+// It serves over stdio by default and over streamable HTTP with -http :8090.
+//
+// Tools: echo returns its text, add sums two numbers, fail always reports an error.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   any             `json:"error,omitempty"`
+}
+
+var tools = []map[string]any{
+	{
+		"name":        "echo",
+		"description": "Returns the given text.",
+		"inputSchema": json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+	},
+	{
+		"name":        "add",
+		"description": "Adds two numbers.",
+		"inputSchema": json.RawMessage(`{"type":"object","properties":{"a":{"type":"number"},"b":{"type":"number"}},"required":["a","b"]}`),
+	},
+	{
+		"name":        "fail",
+		"description": "Always fails, for testing error handling.",
+		"inputSchema": json.RawMessage(`{"type":"object","properties":{}}`),
+	},
+}
+
+func main() {
+	addr := flag.String("http", "", "serve streamable HTTP on this address instead of stdio")
+	flag.Parse()
+	log.SetOutput(os.Stderr)
+
+	if *addr != "" {
+		http.HandleFunc("/mcp", serveHTTP)
+		log.Printf("MCP fixture listening on %s/mcp", *addr)
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var request message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			log.Printf("invalid message: %v", err)
+			continue
+		}
+		if response, ok := handle(request); ok {
+			encoder.Encode(response)
+		}
+	}
+}
+
+// serveHTTP answers tools/call as server-sent event stream and everything else as JSON,
+// so both response formats of the transport are exercised
+func serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request message
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Method == "initialize" {
+		w.Header().Set("Mcp-Session-Id", "fixture-session")
+	} else if r.Header.Get("Mcp-Session-Id") != "fixture-session" {
+		http.Error(w, "missing session", http.StatusBadRequest)
+		return
+	}
+
+	response, ok := handle(request)
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if request.Method == "tools/call" {
+		w.Header().Set("Content-Type", "text/event-stream")
+		progress, _ := json.Marshal(message{JSONRPC: "2.0", Method: "notifications/progress", Params: json.RawMessage(`{"progress":1}`)})
+		data, _ := json.Marshal(response)
+		fmt.Fprintf(w, "data: %s\n\ndata: %s\n\n", progress, data)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handle answers a request, notifications have no response
+func handle(request message) (message, bool) {
+	if len(request.ID) == 0 {
+		return message{}, false
+	}
+	response := message{JSONRPC: "2.0", ID: request.ID}
+	switch request.Method {
+	case "initialize":
+		response.Result = map[string]any{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fixture", "version": "1.0.0"},
+		}
+	case "ping":
+		response.Result = map[string]any{}
+	case "tools/list":
+		response.Result = map[string]any{"tools": tools}
+	case "tools/call":
+		response.Result = call(request.Params)
+	default:
+		response.Error = map[string]any{"code": -32601, "message": "method not found"}
+	}
+	return response, true
+}
+
+func call(params json.RawMessage) map[string]any {
+	var request struct {
+		Name      string `json:"name"`
+		Arguments struct {
+			Text string  `json:"text"`
+			A    float64 `json:"a"`
+			B    float64 `json:"b"`
+		} `json:"arguments"`
+	}
+	json.Unmarshal(params, &request)
+
+	text, isError := "", false
+	switch request.Name {
+	case "echo":
+		text = request.Arguments.Text
+	case "add":
+		text = fmt.Sprint(request.Arguments.A + request.Arguments.B)
+	default:
+		text, isError = "tool "+request.Name+" failed", true
+	}
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
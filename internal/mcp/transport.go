@@ -0,0 +1,296 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errClosed is returned for requests to a server that exited or was closed
+var errClosed = errors.New("connection to MCP server closed")
+
+// transport exchanges JSON-RPC messages with a server
+type transport interface {
+	// roundTrip sends a request and waits for its response
+	roundTrip(ctx context.Context, request message) (message, error)
+	// notify sends a notification, which has no response
+	notify(ctx context.Context, notification message) error
+	close() error
+}
+
+// stdioTransport runs the server as subprocess and exchanges newline-delimited messages over stdin and stdout.
+// The stderr of the server is passed through to the log.
+type stdioTransport struct {
+	name    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan message
+	exited  chan struct{}
+}
+
+func newStdioTransport(name string, server ServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range server.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", server.Command, err)
+	}
+
+	t := &stdioTransport{
+		name:    name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[string]chan message),
+		exited:  make(chan struct{}),
+	}
+	go t.read(stdout)
+	return t, nil
+}
+
+// read dispatches the messages of the server until it closes stdout
+func (t *stdioTransport) read(stdout io.Reader) {
+	defer close(t.exited)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Printf("MCP server %s sent an invalid message: %v", t.name, err)
+			continue
+		}
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			response, ok := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ok {
+				response <- msg
+			}
+		case msg.isRequest():
+			// Sampling and roots are not supported, pings are answered
+			reply := message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")}
+			if msg.Method != "ping" {
+				reply = message{JSONRPC: "2.0", ID: msg.ID, Error: &rpcError{Code: codeMethodNotFound, Message: "method not supported"}}
+			}
+			if err := t.write(reply); err != nil {
+				log.Printf("Failed to answer MCP server %s: %v", t.name, err)
+			}
+		}
+	}
+}
+
+func (t *stdioTransport) write(msg message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(line, '\n'))
+	return err
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, request message) (message, error) {
+	response := make(chan message, 1)
+	t.mu.Lock()
+	t.pending[string(request.ID)] = response
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, string(request.ID))
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(request); err != nil {
+		return message{}, err
+	}
+	select {
+	case msg := <-response:
+		return msg, nil
+	case <-t.exited:
+		return message{}, errClosed
+	case <-ctx.Done():
+		return message{}, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, notification message) error {
+	return t.write(notification)
+}
+
+// close closes stdin, which asks the server to exit, and kills it if it doesn't
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.exited:
+	case <-time.After(5 * time.Second):
+		t.cmd.Process.Kill()
+	}
+	return t.cmd.Wait()
+}
+
+// httpTransport implements the streamable HTTP transport: every message is POSTed to the endpoint,
+// the server answers with JSON or with a stream of server-sent events ending with the response
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func newHTTPTransport(server ServerConfig) *httpTransport {
+	return &httpTransport{url: server.URL, headers: server.Headers, client: &http.Client{}}
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, request message) (message, error) {
+	resp, err := t.post(ctx, request)
+	if err != nil {
+		return message{}, err
+	}
+	defer resp.Body.Close()
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readEvents(resp.Body, request.ID)
+	}
+	var msg message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return message{}, fmt.Errorf("decoding MCP response: %w", err)
+	}
+	return msg, nil
+}
+
+func (t *httpTransport) notify(ctx context.Context, notification message) error {
+	resp, err := t.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// post sends a message within the session, the session is assigned by the server on initialization
+func (t *httpTransport) post(ctx context.Context, msg message) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("MCP server returned %s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+}
+
+// close ends the session, servers without sessions don't need to be told
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// readEvents reads server-sent events until the response to the request arrives.
+// Notifications sent in between, e.g. progress, are skipped.
+func readEvents(body io.Reader, id json.RawMessage) (message, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// An empty line ends the event
+		var msg message
+		err := json.Unmarshal([]byte(data.String()), &msg)
+		data.Reset()
+		if err != nil {
+			return message{}, fmt.Errorf("decoding MCP event: %w", err)
+		}
+		if msg.isResponse() && bytes.Equal(msg.ID, id) {
+			return msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return message{}, err
+	}
+	return message{}, io.ErrUnexpectedEOF
+}
@@ -2,9 +2,9 @@ package middleware
 
 import (
 	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
-	"math/big"
 	"net/http"
 	"strings"
 	"time"
@@ -31,6 +31,7 @@ type JWTClaims struct {
 	GivenName     string                 `json:"given_name"`
 	FamilyName    string                 `json:"family_name"`
 	RealmAccess   map[string]interface{} `json:"realm_access"`
+	Tenant        string                 `json:"tenant"`
 }
 
 // UserInfo contains authenticated user information
@@ -40,6 +41,7 @@ type UserInfo struct {
 	GivenName   string
 	FamilyName  string
 	Roles       []string
+	Tenant      string
 	TokenExpiry time.Time
 }
 
@@ -54,38 +56,42 @@ func MockUserInfo(email string, roles []string) UserInfo {
 	}
 }
 
-// Auth creates the authentication middleware
-func Auth(cfg config.AuthConfig) fiber.Handler {
+// Auth creates the authentication middleware, it fails if the Keycloak public key can't be parsed
+func Auth(cfg config.AuthConfig) (fiber.Handler, error) {
+	publicKey, err := parseKeycloakRSAPublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Keycloak public key: %w", err)
+	}
 	return func(c *fiber.Ctx) error {
 		// Get the Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(http.StatusUnauthorized).JSON(errors.NewUnauthorizedError("Missing authorization header"))
+			return errors.Respond(c, http.StatusUnauthorized, errors.NewUnauthorizedError("Missing authorization header"))
 		}
 
 		// Check if it's a Bearer token
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			return c.Status(http.StatusUnauthorized).JSON(errors.NewUnauthorizedError("Invalid authorization header format"))
+			return errors.Respond(c, http.StatusUnauthorized, errors.NewUnauthorizedError("Invalid authorization header format"))
 		}
 
 		// Extract the token
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// Parse and validate the token
-		userInfo, err := validateToken(tokenString, cfg)
+		userInfo, err := validateToken(tokenString, publicKey)
 		if err != nil {
-			return c.Status(http.StatusUnauthorized).JSON(errors.NewUnauthorizedError(fmt.Sprintf("Invalid token: %v", err)))
+			return errors.Respond(c, http.StatusUnauthorized, errors.NewUnauthorizedError(fmt.Sprintf("Invalid token: %v", err)))
 		}
 
 		// Store user information in context
 		c.Locals(string(UserKey), userInfo)
 
 		return c.Next()
-	}
+	}, nil
 }
 
 // validateToken validates the JWT token
-func validateToken(tokenString string, cfg config.AuthConfig) (*UserInfo, error) {
+func validateToken(tokenString string, publicKey *rsa.PublicKey) (*UserInfo, error) {
 	// Parse the token using the public key
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Verify signing method
@@ -93,12 +99,6 @@ func validateToken(tokenString string, cfg config.AuthConfig) (*UserInfo, error)
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		// Get the public key
-		publicKey, err := parseKeycloakRSAPublicKey(cfg.PublicKey)
-		if err != nil {
-			return nil, err
-		}
-
 		return publicKey, nil
 	})
 
@@ -133,19 +133,23 @@ func validateToken(tokenString string, cfg config.AuthConfig) (*UserInfo, error)
 		GivenName:   claims.GivenName,
 		FamilyName:  claims.FamilyName,
 		Roles:       roles,
+		Tenant:      claims.Tenant,
 		TokenExpiry: claims.ExpiresAt.Time,
 	}
 
 	return userInfo, nil
 }
 
-// parseKeycloakRSAPublicKey parses the public key from Keycloak
+// parseKeycloakRSAPublicKey parses the public key from Keycloak, given as base64 DER with or without PEM armor
 func parseKeycloakRSAPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
 	// Remove PEM formatting if present
 	publicKeyPEM = strings.TrimSpace(publicKeyPEM)
 	publicKeyPEM = strings.TrimPrefix(publicKeyPEM, "-----BEGIN PUBLIC KEY-----")
 	publicKeyPEM = strings.TrimSuffix(publicKeyPEM, "-----END PUBLIC KEY-----")
-	publicKeyPEM = strings.ReplaceAll(publicKeyPEM, "\n", "")
+	publicKeyPEM = strings.Join(strings.Fields(publicKeyPEM), "")
+	if publicKeyPEM == "" {
+		return nil, fmt.Errorf("KEYCLOAK_PUBLIC_KEY is not set")
+	}
 
 	// Decode the base64 encoded DER format
 	derBytes, err := base64.StdEncoding.DecodeString(publicKeyPEM)
@@ -153,17 +157,14 @@ func parseKeycloakRSAPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
 		return nil, err
 	}
 
-	// Parse the key (simplified for this example)
-	// In a real implementation, you would use x509.ParsePKIXPublicKey
-	e := big.NewInt(65537) // Default public exponent
-	n := new(big.Int).SetBytes(derBytes)
-
-	// Create an RSA public key
-	pubKey := &rsa.PublicKey{
-		N: n,
-		E: int(e.Int64()),
+	key, err := x509.ParsePKIXPublicKey(derBytes)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA public key")
 	}
-
 	return pubKey, nil
 }
 
@@ -176,12 +177,24 @@ func GetCurrentUser(c *fiber.Ctx) *UserInfo {
 	return userInfo
 }
 
+// MockAuth authenticates every request without a user as the given user.
+// It stands in for Auth during local development, see AUTH_MOCK_ENABLED.
+func MockAuth(user UserInfo) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetCurrentUser(c) == nil {
+			mockUser := user
+			c.Locals(string(UserKey), &mockUser)
+		}
+		return c.Next()
+	}
+}
+
 // RequireRoles creates middleware to check if the user has specific roles
 func RequireRoles(roles ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := GetCurrentUser(c)
 		if user == nil {
-			return c.Status(http.StatusUnauthorized).JSON(errors.NewUnauthorizedError(""))
+			return errors.Respond(c, http.StatusUnauthorized, errors.NewUnauthorizedError(""))
 		}
 
 		// Check if the user has any of the required roles
@@ -199,7 +212,7 @@ func RequireRoles(roles ...string) fiber.Handler {
 		}
 
 		if !hasRole {
-			return c.Status(http.StatusForbidden).JSON(errors.NewForbiddenError(""))
+			return errors.Respond(c, http.StatusForbidden, errors.NewForbiddenError(""))
 		}
 
 		return c.Next()
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/ratelimit"
+)
+
+// RateLimit creates middleware limiting requests per authenticated user and tenant.
+// The scope separates the buckets of different endpoints, e.g. chat and message creation.
+func RateLimit(store ratelimit.Store, scope string, limit ratelimit.Limit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, err := store.Take(c.UserContext(), rateLimitKey(c, scope), limit)
+		if err != nil {
+			// Fail open, an unavailable store must not take the API down
+			log.Printf("Rate limiting failed: %v", err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			return errors.NewAppError(errors.RateLimitedError, "Too many requests, please try again later").WithRetryAfter(result.RetryAfter)
+		}
+		return c.Next()
+	}
+}
+
+// rateLimitKey identifies the bucket of the current user, falling back to the client IP
+func rateLimitKey(c *fiber.Ctx, scope string) string {
+	if user := GetCurrentUser(c); user != nil {
+		return UserRateLimitKey(scope, user)
+	}
+	return scope + ":ip:" + c.IP()
+}
+
+// UserRateLimitKey identifies the bucket of the user, for limits applied outside of the middleware
+func UserRateLimitKey(scope string, user *UserInfo) string {
+	return scope + ":user:" + user.Tenant + ":" + user.Email
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/opensearch"
+)
+
+// RequestLogger creates middleware that ships a structured log entry for every request to the sink
+func RequestLogger(sink *opensearch.Sink) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if sink == nil {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+
+		// The error handler has not run yet, so derive the status code from the error like it does
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+
+		entry := opensearch.RequestLog{
+			Timestamp: start.UTC(),
+			Type:      opensearch.RequestDocument,
+			Method:    c.Method(),
+			Path:      c.Path(),
+			Route:     c.Route().Path,
+			Status:    status,
+			LatencyMs: time.Since(start).Milliseconds(),
+			IP:        c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		}
+		if user := GetCurrentUser(c); user != nil {
+			entry.UserEmail = user.Email
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		sink.Index(entry)
+
+		return err
+	}
+}
+
+// errorStatus returns the status code the error handler responds to the error with
+func errorStatus(err error) int {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Status
+	}
+	var e *fiber.Error
+	if stderrors.As(err, &e) {
+		return e.Code
+	}
+	return fiber.StatusInternalServerError
+}
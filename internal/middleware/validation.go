@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/errors"
+)
+
+func init() {
+	// kin-openapi only validates the formats it has been told about
+	openapi3.DefineStringFormatValidator("uuid", openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForUUIDOfRFC4122))
+}
+
+// RequestValidator creates middleware that validates path parameters, content types and
+// request bodies against the OpenAPI spec. Violations are returned as VALIDATION_ERROR
+// with one ErrorDetail per offending field. Requests for routes not in the spec are passed through.
+func RequestValidator(cfg config.ValidationConfig) (fiber.Handler, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromFile(cfg.SpecPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	if cfg.MaxContentLength > 0 {
+		limitContentLength(doc, uint64(cfg.MaxContentLength))
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("creating OpenAPI router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError: true,
+		// Authentication is handled by the auth middleware
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *fiber.Ctx) error {
+		req, err := adaptor.ConvertRequest(c, false)
+		if err != nil {
+			return err
+		}
+
+		route, pathParams, err := router.FindRoute(req)
+		if stderrors.Is(err, routers.ErrPathNotFound) || stderrors.Is(err, routers.ErrMethodNotAllowed) {
+			return c.Next()
+		}
+		if err != nil {
+			return err
+		}
+
+		err = openapi3filter.ValidateRequest(context.Background(), &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			return errors.NewAppError(errors.ValidationError, "The request contains invalid parameters", validationDetails(err, "body")...)
+		}
+
+		return c.Next()
+	}, nil
+}
+
+// limitContentLength caps the "content" property of every request body, e.g. the message content
+func limitContentLength(doc *openapi3.T, maxLength uint64) {
+	for _, pathItem := range doc.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+				continue
+			}
+			for _, media := range operation.RequestBody.Value.Content {
+				if media.Schema == nil || media.Schema.Value == nil {
+					continue
+				}
+				if content := media.Schema.Value.Properties["content"]; content != nil && content.Value != nil {
+					content.Value.MaxLength = &maxLength
+				}
+			}
+		}
+	}
+}
+
+// validationDetails flattens the errors reported by kin-openapi into one detail per field
+func validationDetails(err error, field string) []errors.ErrorDetail {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var details []errors.ErrorDetail
+		for _, inner := range e {
+			details = append(details, validationDetails(inner, field)...)
+		}
+		return details
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		if e.RequestBody != nil && strings.Contains(e.Reason, fiber.HeaderContentType) {
+			field = fiber.HeaderContentType
+		}
+		if e.Err == nil {
+			return []errors.ErrorDetail{{Field: field, Value: e.Reason}}
+		}
+		return validationDetails(e.Err, field)
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			field = strings.Join(pointer, ".")
+		}
+		return []errors.ErrorDetail{{Field: field, Value: e.Reason}}
+	default:
+		return []errors.ErrorDetail{{Field: field, Value: err.Error()}}
+	}
+}
@@ -0,0 +1,114 @@
+package opensearch
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentType distinguishes the kinds of documents written to the index
+type DocumentType string
+
+const (
+	// RequestDocument is a structured HTTP request log entry
+	RequestDocument DocumentType = "request"
+	// AuditDocument is a chat audit event
+	AuditDocument DocumentType = "audit"
+)
+
+// AuditAction identifies what happened in a chat audit event
+type AuditAction string
+
+const (
+	// ChatCreatedAction is recorded when a user starts a new chat
+	ChatCreatedAction AuditAction = "chat_created"
+	// ChatDeletedAction is recorded when a user deletes a chat
+	ChatDeletedAction AuditAction = "chat_deleted"
+	// MessageSentAction is recorded when a user sends a message
+	MessageSentAction AuditAction = "message_sent"
+	// LLMReplyAction is recorded when the LLM answered a message
+	LLMReplyAction AuditAction = "llm_reply"
+	// ToolCalledAction is recorded when the LLM called a tool while answering
+	ToolCalledAction AuditAction = "tool_called"
+	// ChatExportedAction is recorded when a user downloads the transcript of a chat
+	ChatExportedAction AuditAction = "chat_exported"
+)
+
+// RequestLog is a structured log entry for a single HTTP request
+type RequestLog struct {
+	Timestamp time.Time    `json:"@timestamp"`
+	Type      DocumentType `json:"type"`
+	Method    string       `json:"method"`
+	Path      string       `json:"path"`
+	Route     string       `json:"route,omitempty"`
+	Status    int          `json:"status"`
+	LatencyMs int64        `json:"latencyMs"`
+	IP        string       `json:"ip,omitempty"`
+	UserAgent string       `json:"userAgent,omitempty"`
+	UserEmail string       `json:"userEmail,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// AuditEvent records a user-visible action on a chat
+type AuditEvent struct {
+	Timestamp time.Time    `json:"@timestamp"`
+	Type      DocumentType `json:"type"`
+	Action    AuditAction  `json:"action"`
+	UserEmail string       `json:"userEmail"`
+	ChatID    string       `json:"chatId"`
+	MessageID string       `json:"messageId,omitempty"`
+	LatencyMs int64        `json:"latencyMs,omitempty"`
+	Tool      string       `json:"tool,omitempty"`
+	Format    string       `json:"format,omitempty"`
+}
+
+// NewAuditEvent creates an audit event for the given chat and message
+func NewAuditEvent(action AuditAction, userEmail string, chatID, messageID uuid.UUID) AuditEvent {
+	event := AuditEvent{
+		Timestamp: time.Now().UTC(),
+		Type:      AuditDocument,
+		Action:    action,
+		UserEmail: userEmail,
+		ChatID:    chatID.String(),
+	}
+	if messageID != uuid.Nil {
+		event.MessageID = messageID.String()
+	}
+	return event
+}
+
+// ChatCreated creates the audit event for a newly created chat
+func ChatCreated(userEmail string, chatID uuid.UUID) AuditEvent {
+	return NewAuditEvent(ChatCreatedAction, userEmail, chatID, uuid.Nil)
+}
+
+// ChatDeleted creates the audit event for a chat deleted by the user
+func ChatDeleted(userEmail string, chatID uuid.UUID) AuditEvent {
+	return NewAuditEvent(ChatDeletedAction, userEmail, chatID, uuid.Nil)
+}
+
+// MessageSent creates the audit event for a message sent by the user
+func MessageSent(userEmail string, chatID, messageID uuid.UUID) AuditEvent {
+	return NewAuditEvent(MessageSentAction, userEmail, chatID, messageID)
+}
+
+// LLMReply creates the audit event for an LLM reply including the generation latency
+func LLMReply(userEmail string, chatID, messageID uuid.UUID, latency time.Duration) AuditEvent {
+	event := NewAuditEvent(LLMReplyAction, userEmail, chatID, messageID)
+	event.LatencyMs = latency.Milliseconds()
+	return event
+}
+
+// ToolCalled creates the audit event for a tool call, the message records the call and its result
+func ToolCalled(userEmail string, chatID, messageID uuid.UUID, tool string) AuditEvent {
+	event := NewAuditEvent(ToolCalledAction, userEmail, chatID, messageID)
+	event.Tool = tool
+	return event
+}
+
+// ChatExported creates the audit event for a chat transcript downloaded in the given format
+func ChatExported(userEmail string, chatID uuid.UUID, format string) AuditEvent {
+	event := NewAuditEvent(ChatExportedAction, userEmail, chatID, uuid.Nil)
+	event.Format = format
+	return event
+}
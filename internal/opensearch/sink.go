@@ -0,0 +1,269 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+)
+
+// Sink asynchronously ships documents to an OpenSearch index using the _bulk API.
+// Documents are queued in memory, flushed in batches and retried on transient failures.
+// A nil *Sink is valid and silently discards everything, so callers don't need to
+// check whether shipping is enabled.
+type Sink struct {
+	cfg    config.OpenSearchConfig
+	client *http.Client
+	queue  chan any
+
+	dropped atomic.Int64
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// bulkResponse is the subset of the _bulk response we need to detect failed items
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error,omitempty"`
+	} `json:"items"`
+}
+
+// NewSink creates a sink and starts its background flusher
+func NewSink(cfg config.OpenSearchConfig) *Sink {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+
+	s := &Sink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.RequestTimeout},
+		queue:  make(chan any, cfg.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Index queues a document for indexing. When the queue is full it waits up to
+// the configured enqueue timeout before dropping the document, so a slow or
+// unavailable cluster never blocks request handling for long.
+func (s *Sink) Index(doc any) {
+	if s == nil {
+		return
+	}
+
+	select {
+	case <-s.stop:
+		s.dropped.Add(1)
+		return
+	default:
+	}
+
+	select {
+	case s.queue <- doc:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(s.cfg.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case s.queue <- doc:
+	case <-timer.C:
+		if n := s.dropped.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("OpenSearch queue full, %d documents dropped so far", n)
+		}
+	case <-s.stop:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of documents discarded because of backpressure or permanent failures
+func (s *Sink) Dropped() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.dropped.Load()
+}
+
+// Close stops accepting documents and flushes what is still queued until ctx expires
+func (s *Sink) Close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	s.closeOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run collects queued documents into batches and flushes them by size or interval
+func (s *Sink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]any, 0, s.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.send(batch)
+		batch = make([]any, 0, s.cfg.BatchSize)
+	}
+
+	for {
+		select {
+		case doc := <-s.queue:
+			batch = append(batch, doc)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			// Drain whatever made it into the queue before shutdown
+			for {
+				select {
+				case doc := <-s.queue:
+					batch = append(batch, doc)
+					if len(batch) >= s.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send ships a batch, retrying the documents that failed with a retryable status
+func (s *Sink) send(docs []any) {
+	backoff := s.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.bulk(docs)
+		if err == nil && len(retry) == 0 {
+			return
+		}
+
+		// A transport error or a rejected request means the whole batch has to be retried
+		if err != nil {
+			retry = docs
+		} else {
+			err = fmt.Errorf("%d documents failed with a retryable status", len(retry))
+		}
+
+		if attempt >= s.cfg.MaxRetries {
+			s.dropped.Add(int64(len(retry)))
+			log.Printf("OpenSearch bulk indexing gave up on %d documents after %d attempts: %v", len(retry), attempt+1, err)
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		docs = retry
+	}
+}
+
+// bulk performs a single _bulk request and returns the documents worth retrying
+func (s *Sink) bulk(docs []any) ([]any, error) {
+	var body bytes.Buffer
+	action := fmt.Sprintf(`{"index":{"_index":%q}}`, s.cfg.Index)
+	encoded := make([]any, 0, len(docs))
+	for _, doc := range docs {
+		source, err := json.Marshal(doc)
+		if err != nil {
+			log.Printf("OpenSearch dropping document that cannot be encoded: %v", err)
+			s.dropped.Add(1)
+			continue
+		}
+		body.WriteString(action)
+		body.WriteByte('\n')
+		body.Write(source)
+		body.WriteByte('\n')
+		encoded = append(encoded, doc)
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	url := strings.TrimRight(s.cfg.URL, "/") + "/_bulk"
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("bulk request failed with status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Printf("OpenSearch rejected bulk request with status %d: %s", resp.StatusCode, msg)
+		s.dropped.Add(int64(len(encoded)))
+		return nil, nil
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+
+	var retry []any
+	for i, item := range result.Items {
+		if i >= len(encoded) {
+			break
+		}
+		for _, res := range item {
+			switch {
+			case res.Status == http.StatusTooManyRequests || res.Status >= 500:
+				retry = append(retry, encoded[i])
+			case res.Status >= 300:
+				log.Printf("OpenSearch rejected document with status %d: %s", res.Status, res.Error)
+				s.dropped.Add(1)
+			}
+		}
+	}
+	return retry, nil
+}
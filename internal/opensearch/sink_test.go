@@ -0,0 +1,380 @@
+package opensearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+)
+
+type testDoc struct {
+	N int `json:"n"`
+}
+
+// bulkServer is a fake _bulk endpoint that records the documents of every request and answers with the
+// responses of the test, a successful bulk response once they run out
+type bulkServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	requests  [][]int
+	responses []func(w http.ResponseWriter, docs []int)
+	received  chan []int
+}
+
+func newBulkServer(t *testing.T, responses ...func(w http.ResponseWriter, docs []int)) *bulkServer {
+	t.Helper()
+	s := &bulkServer{responses: responses, received: make(chan []int, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *bulkServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/_bulk" {
+		http.Error(w, "unexpected request", http.StatusNotFound)
+		return
+	}
+	var docs []int
+	scanner := bufio.NewScanner(r.Body)
+	for line := 0; scanner.Scan(); line++ {
+		// Every document follows its action line
+		if line%2 == 0 {
+			continue
+		}
+		var doc testDoc
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		docs = append(docs, doc.N)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, docs)
+	var respond func(w http.ResponseWriter, docs []int)
+	if len(s.responses) > 0 {
+		respond, s.responses = s.responses[0], s.responses[1:]
+	}
+	s.mu.Unlock()
+
+	if respond == nil {
+		respond = respondItems()
+	}
+	respond(w, docs)
+	s.received <- docs
+}
+
+// next waits for the documents of the next bulk request
+func (s *bulkServer) next(t *testing.T) []int {
+	t.Helper()
+	select {
+	case docs := <-s.received:
+		return docs
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a bulk request")
+		return nil
+	}
+}
+
+// expectNoRequest fails if a bulk request arrives within the wait
+func (s *bulkServer) expectNoRequest(t *testing.T, wait time.Duration) {
+	t.Helper()
+	select {
+	case docs := <-s.received:
+		t.Fatalf("unexpected bulk request with documents %v", docs)
+	case <-time.After(wait):
+	}
+}
+
+func (s *bulkServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// respondStatus fails the whole request with the status
+func respondStatus(status int) func(w http.ResponseWriter, docs []int) {
+	return func(w http.ResponseWriter, docs []int) {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// respondItems answers with the item statuses in the order of the documents, 201 for documents without a status
+func respondItems(statuses ...int) func(w http.ResponseWriter, docs []int) {
+	return func(w http.ResponseWriter, docs []int) {
+		var result bulkResponse
+		for i := range docs {
+			status := http.StatusCreated
+			if i < len(statuses) {
+				status = statuses[i]
+			}
+			item := struct {
+				Status int             `json:"status"`
+				Error  json.RawMessage `json:"error,omitempty"`
+			}{Status: status}
+			if status >= 300 {
+				result.Errors = true
+				item.Error = json.RawMessage(fmt.Sprintf(`{"type":"status_%d"}`, status))
+			}
+			result.Items = append(result.Items, map[string]struct {
+				Status int             `json:"status"`
+				Error  json.RawMessage `json:"error,omitempty"`
+			}{"index": item})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func newTestSink(t *testing.T, url string, modify func(cfg *config.OpenSearchConfig)) *Sink {
+	t.Helper()
+	cfg := config.OpenSearchConfig{
+		Enabled:        true,
+		URL:            url,
+		Index:          "test-events",
+		BatchSize:      100,
+		FlushInterval:  time.Hour,
+		QueueSize:      100,
+		EnqueueTimeout: 10 * time.Millisecond,
+		RequestTimeout: 2 * time.Second,
+		MaxRetries:     3,
+		RetryBackoff:   time.Millisecond,
+	}
+	if modify != nil {
+		modify(&cfg)
+	}
+	sink := NewSink(cfg)
+	t.Cleanup(func() { closeSink(t, sink) })
+	return sink
+}
+
+func closeSink(t *testing.T, sink *Sink) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := sink.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func indexDocs(sink *Sink, from, to int) {
+	for n := from; n <= to; n++ {
+		sink.Index(testDoc{N: n})
+	}
+}
+
+func TestSinkFlushesFullBatches(t *testing.T) {
+	server := newBulkServer(t)
+	sink := newTestSink(t, server.URL, func(cfg *config.OpenSearchConfig) {
+		cfg.BatchSize = 3
+	})
+
+	indexDocs(sink, 1, 7)
+
+	if docs := server.next(t); !slices.Equal(docs, []int{1, 2, 3}) {
+		t.Errorf("first batch = %v, want [1 2 3]", docs)
+	}
+	if docs := server.next(t); !slices.Equal(docs, []int{4, 5, 6}) {
+		t.Errorf("second batch = %v, want [4 5 6]", docs)
+	}
+	// The incomplete batch waits for the flush interval
+	server.expectNoRequest(t, 50*time.Millisecond)
+}
+
+func TestSinkFlushesOnInterval(t *testing.T) {
+	server := newBulkServer(t)
+	sink := newTestSink(t, server.URL, func(cfg *config.OpenSearchConfig) {
+		cfg.FlushInterval = 20 * time.Millisecond
+	})
+
+	indexDocs(sink, 1, 2)
+
+	if docs := server.next(t); !slices.Equal(docs, []int{1, 2}) {
+		t.Errorf("batch = %v, want [1 2]", docs)
+	}
+}
+
+func TestSinkRetriesWholeBatchOnRetryableStatus(t *testing.T) {
+	server := newBulkServer(t,
+		respondStatus(http.StatusTooManyRequests),
+		respondStatus(http.StatusServiceUnavailable),
+	)
+	sink := newTestSink(t, server.URL, nil)
+
+	indexDocs(sink, 1, 3)
+	closeSink(t, sink)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if docs := server.next(t); !slices.Equal(docs, []int{1, 2, 3}) {
+			t.Errorf("attempt %d sent %v, want [1 2 3]", attempt, docs)
+		}
+	}
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Errorf("Dropped() = %d, want 0", dropped)
+	}
+}
+
+func TestSinkDropsBatchAfterMaxRetries(t *testing.T) {
+	server := newBulkServer(t,
+		respondStatus(http.StatusInternalServerError),
+		respondStatus(http.StatusInternalServerError),
+		respondStatus(http.StatusInternalServerError),
+	)
+	sink := newTestSink(t, server.URL, func(cfg *config.OpenSearchConfig) {
+		cfg.MaxRetries = 2
+	})
+
+	indexDocs(sink, 1, 4)
+	closeSink(t, sink)
+
+	if count := server.requestCount(); count != 3 {
+		t.Errorf("sent %d requests, want 3", count)
+	}
+	if dropped := sink.Dropped(); dropped != 4 {
+		t.Errorf("Dropped() = %d, want 4", dropped)
+	}
+}
+
+func TestSinkDropsRejectedRequest(t *testing.T) {
+	server := newBulkServer(t, respondStatus(http.StatusBadRequest))
+	sink := newTestSink(t, server.URL, nil)
+
+	indexDocs(sink, 1, 2)
+	closeSink(t, sink)
+
+	if count := server.requestCount(); count != 1 {
+		t.Errorf("sent %d requests, want 1", count)
+	}
+	if dropped := sink.Dropped(); dropped != 2 {
+		t.Errorf("Dropped() = %d, want 2", dropped)
+	}
+}
+
+func TestSinkRetriesOnlyRetryableItems(t *testing.T) {
+	server := newBulkServer(t,
+		respondItems(http.StatusCreated, http.StatusTooManyRequests, http.StatusBadRequest, http.StatusServiceUnavailable, http.StatusCreated),
+	)
+	sink := newTestSink(t, server.URL, nil)
+
+	indexDocs(sink, 1, 5)
+	closeSink(t, sink)
+
+	if docs := server.next(t); !slices.Equal(docs, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("first attempt sent %v, want [1 2 3 4 5]", docs)
+	}
+	if docs := server.next(t); !slices.Equal(docs, []int{2, 4}) {
+		t.Errorf("retry sent %v, want [2 4]", docs)
+	}
+	// Only the document rejected with a non-retryable status is lost
+	if dropped := sink.Dropped(); dropped != 1 {
+		t.Errorf("Dropped() = %d, want 1", dropped)
+	}
+}
+
+func TestSinkCountsDroppedDocumentsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	blocked := func(w http.ResponseWriter, docs []int) {
+		<-release
+		respondItems()(w, docs)
+	}
+	server := newBulkServer(t, blocked)
+	sink := newTestSink(t, server.URL, func(cfg *config.OpenSearchConfig) {
+		cfg.BatchSize = 1
+		cfg.QueueSize = 1
+	})
+
+	// The first document is taken from the queue and sent, the flusher then waits for the cluster
+	sink.Index(testDoc{N: 1})
+	waitFor(t, func() bool { return server.requestCount() == 1 })
+
+	// The second document fills the queue, the rest has to be dropped
+	indexDocs(sink, 2, 4)
+	if dropped := sink.Dropped(); dropped != 2 {
+		t.Errorf("Dropped() = %d, want 2", dropped)
+	}
+
+	close(release)
+	closeSink(t, sink)
+	if docs := server.next(t); !slices.Equal(docs, []int{1}) {
+		t.Errorf("first batch = %v, want [1]", docs)
+	}
+	if docs := server.next(t); !slices.Equal(docs, []int{2}) {
+		t.Errorf("second batch = %v, want [2]", docs)
+	}
+}
+
+func TestSinkCloseFlushesQueuedDocuments(t *testing.T) {
+	server := newBulkServer(t)
+	sink := newTestSink(t, server.URL, func(cfg *config.OpenSearchConfig) {
+		cfg.BatchSize = 4
+	})
+
+	indexDocs(sink, 1, 6)
+	closeSink(t, sink)
+
+	if docs := server.next(t); !slices.Equal(docs, []int{1, 2, 3, 4}) {
+		t.Errorf("full batch = %v, want [1 2 3 4]", docs)
+	}
+	if docs := server.next(t); !slices.Equal(docs, []int{5, 6}) {
+		t.Errorf("flushed batch = %v, want [5 6]", docs)
+	}
+
+	// Documents indexed after Close are dropped instead of queued
+	sink.Index(testDoc{N: 7})
+	if dropped := sink.Dropped(); dropped != 1 {
+		t.Errorf("Dropped() = %d, want 1", dropped)
+	}
+}
+
+func TestNilSinkDiscardsDocuments(t *testing.T) {
+	var sink *Sink
+	sink.Index(testDoc{N: 1})
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Errorf("Dropped() = %d, want 0", dropped)
+	}
+	if err := sink.Close(context.Background()); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestSinkSendsIndexAction(t *testing.T) {
+	var action string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		if scanner.Scan() {
+			action = scanner.Text()
+		}
+		respondItems()(w, []int{0})
+	}))
+	defer server.Close()
+	sink := newTestSink(t, server.URL+"/", nil)
+
+	sink.Index(testDoc{N: 1})
+	closeSink(t, sink)
+
+	if !strings.Contains(action, `"_index":"test-events"`) {
+		t.Errorf("action = %s, want the configured index", action)
+	}
+}
+
+// waitFor polls the condition until it holds or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
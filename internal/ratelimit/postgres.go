@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"ai-chat-service-go/internal/database"
+)
+
+// staleBucketAge is how long a bucket in Postgres may be unused before it is deleted
+const staleBucketAge = 24 * time.Hour
+
+// PostgresStore keeps token buckets in the rate_limit_buckets table so limits apply across replicas
+type PostgresStore struct {
+	queries     *database.Queries
+	lastCleanup atomic.Int64
+}
+
+// NewPostgresStore creates a store backed by Postgres
+func NewPostgresStore(queries *database.Queries) *PostgresStore {
+	s := &PostgresStore{queries: queries}
+	s.lastCleanup.Store(time.Now().UnixNano())
+	return s
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.cleanup(ctx)
+
+	row, err := s.queries.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(row.Allowed, row.Tokens, limit), nil
+}
+
+// cleanup deletes stale buckets, at most once per cleanup interval across all requests
+func (s *PostgresStore) cleanup(ctx context.Context) {
+	last := s.lastCleanup.Load()
+	now := time.Now()
+	if now.Sub(time.Unix(0, last)) < cleanupInterval || !s.lastCleanup.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	if err := s.queries.DeleteStaleRateLimitBuckets(ctx, now.Add(-staleBucketAge)); err != nil {
+		log.Printf("Failed to delete stale rate limit buckets: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Burst tokens at most, refilled at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute creates a limit allowing n requests per minute with the given burst
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long to wait for the next token if the request was not allowed
+	RetryAfter time.Duration
+	// Reset is how long it takes until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the token buckets
+type Store interface {
+	// Take takes a token from the bucket identified by key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// newResult computes the result for a bucket holding tokens after the request was decided
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if limit.Rate > 0 {
+		result.Reset = secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate)
+		if !allowed {
+			result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+		}
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Max(0, seconds) * float64(time.Second))
+}
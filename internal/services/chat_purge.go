@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// purgeLockKey identifies the Postgres advisory lock held by the instance that purges, any fixed number works
+// as long as no other job of the database uses it
+const purgeLockKey int64 = 0x63686174_70757267
+
+// PurgeResult counts the rows a purge run removed, or would have removed in dry-run mode. Messages are removed
+// with their chats and counted for both kinds of chats, ExpiredMessages were removed from chats that are kept.
+type PurgeResult struct {
+	DeletedChats    int64
+	InactiveChats   int64
+	Messages        int64
+	ExpiredMessages int64
+}
+
+// ChatPurger removes deleted chats once the retention period for deleted chats is over and chats that were
+// inactive for longer than the retention period of their tenant, with their messages, and messages older than
+// the message retention period of their tenant. With several instances running, only the one holding the
+// advisory lock purges, the others skip the run.
+type ChatPurger struct {
+	cfg     config.ChatsConfig
+	db      *sql.DB
+	queries *database.Queries
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewChatPurger creates the purge job for deleted and inactive chats and old messages
+func NewChatPurger(cfg config.ChatsConfig, db *sql.DB, queries *database.Queries) *ChatPurger {
+	return &ChatPurger{
+		cfg:     cfg,
+		db:      db,
+		queries: queries,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// PurgeEnabled reports whether any retention period is configured and the purge job has to run
+func PurgeEnabled(cfg config.ChatsConfig) bool {
+	if cfg.DeletedRetention > 0 || cfg.InactiveRetention > 0 || cfg.MessageRetention > 0 {
+		return true
+	}
+	for _, retention := range cfg.TenantInactiveRetention {
+		if retention > 0 {
+			return true
+		}
+	}
+	for _, retention := range cfg.TenantMessageRetention {
+		if retention > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Start purges now and then every purge interval until the purger is closed
+func (p *ChatPurger) Start(ctx context.Context) {
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.cfg.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			result, purged, err := p.Run(ctx)
+			switch {
+			case err != nil:
+				log.Printf("Failed to purge chats: %v", err)
+			case purged && p.cfg.PurgeDryRun:
+				log.Printf("Purge dry run: would purge %d deleted and %d inactive chats with %d messages and %d expired messages",
+					result.DeletedChats, result.InactiveChats, result.Messages, result.ExpiredMessages)
+			case result.DeletedChats > 0 || result.InactiveChats > 0 || result.ExpiredMessages > 0:
+				log.Printf("Purged %d deleted and %d inactive chats with %d messages and %d expired messages",
+					result.DeletedChats, result.InactiveChats, result.Messages, result.ExpiredMessages)
+			}
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the purger after the current batch
+func (p *ChatPurger) Close(ctx context.Context) error {
+	close(p.stop)
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run purges if no other instance is purging and records the run in purge_runs. It reports false
+// if another instance holds the lock.
+func (p *ChatPurger) Run(ctx context.Context) (PurgeResult, bool, error) {
+	// The advisory lock belongs to the session, so it is taken and released on a connection of its own
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return PurgeResult{}, false, err
+	}
+	locks := database.New(conn)
+	acquired, err := locks.TryAdvisoryLock(ctx, purgeLockKey)
+	if err != nil || !acquired {
+		conn.Close()
+		return PurgeResult{}, false, err
+	}
+	defer func() {
+		if err := locks.ReleaseAdvisoryLock(context.WithoutCancel(ctx), purgeLockKey); err != nil {
+			// Closing the connection releases the lock, returned to the pool it would keep holding it
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}()
+
+	startedAt := time.Now().UTC()
+	result, err := p.Purge(ctx)
+	run := database.CreatePurgeRunParams{
+		ID:              uuid.New(),
+		DryRun:          p.cfg.PurgeDryRun,
+		DeletedChats:    int32(result.DeletedChats),
+		InactiveChats:   int32(result.InactiveChats),
+		Messages:        int32(result.Messages),
+		ExpiredMessages: int32(result.ExpiredMessages),
+		StartedAt:       startedAt,
+		FinishedAt:      time.Now().UTC(),
+	}
+	if err != nil {
+		run.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if recordErr := p.queries.CreatePurgeRun(context.WithoutCancel(ctx), run); recordErr != nil {
+		log.Printf("Failed to record purge run: %v", recordErr)
+	}
+	return result, true, err
+}
+
+// Purge removes the chats deleted before the retention period, the chats inactive for longer than the retention
+// period of their tenant and the messages older than the message retention period of their tenant in batches, or
+// only counts them in dry-run mode. It stops after the current batch if the purger is closed.
+func (p *ChatPurger) Purge(ctx context.Context) (PurgeResult, error) {
+	var result PurgeResult
+	now := time.Now().UTC()
+	if p.cfg.DeletedRetention > 0 {
+		chats, messages, err := p.purgeDeleted(ctx, now.Add(-p.cfg.DeletedRetention))
+		result.DeletedChats += chats
+		result.Messages += messages
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if p.cfg.InactiveRetention <= 0 && len(p.cfg.TenantInactiveRetention) == 0 &&
+		p.cfg.MessageRetention <= 0 && len(p.cfg.TenantMessageRetention) == 0 {
+		return result, nil
+	}
+	tenants, err := p.queries.ListChatTenants(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, tenant := range tenants {
+		// Inactive chats go first, their messages don't have to be expired one by one
+		retention := tenantRetention(p.cfg.TenantInactiveRetention, p.cfg.InactiveRetention, tenant)
+		if retention > 0 && !p.stopped() {
+			chats, messages, err := p.purgeInactive(ctx, tenant, now.Add(-retention))
+			result.InactiveChats += chats
+			result.Messages += messages
+			if err != nil {
+				return result, err
+			}
+		}
+		retention = tenantRetention(p.cfg.TenantMessageRetention, p.cfg.MessageRetention, tenant)
+		if retention > 0 && !p.stopped() {
+			expired, err := p.expireMessages(ctx, tenant, now.Add(-retention))
+			result.ExpiredMessages += expired
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// tenantRetention returns the retention period of the tenant, the global one unless the tenant has its own
+func tenantRetention(tenants map[string]time.Duration, global time.Duration, tenant string) time.Duration {
+	if retention, ok := tenants[tenant]; ok {
+		return retention
+	}
+	return global
+}
+
+func (p *ChatPurger) purgeDeleted(ctx context.Context, cutoff time.Time) (int64, int64, error) {
+	deletedBefore := sql.NullTime{Time: cutoff, Valid: true}
+	if p.cfg.PurgeDryRun {
+		counted, err := p.queries.CountDeletedChats(ctx, deletedBefore)
+		return counted.Chats, counted.Messages, err
+	}
+	batchSize := max(p.cfg.PurgeBatchSize, 1)
+	var chats, messages int64
+	for {
+		purged, err := p.queries.PurgeDeletedChats(ctx, database.PurgeDeletedChatsParams{
+			DeletedBefore: deletedBefore,
+			BatchSize:     batchSize,
+		})
+		chats += purged.Chats
+		messages += purged.Messages
+		if err != nil || purged.Chats < int64(batchSize) || p.stopped() {
+			return chats, messages, err
+		}
+	}
+}
+
+func (p *ChatPurger) purgeInactive(ctx context.Context, tenant string, cutoff time.Time) (int64, int64, error) {
+	if p.cfg.PurgeDryRun {
+		counted, err := p.queries.CountInactiveChats(ctx, database.CountInactiveChatsParams{
+			Tenant:         tenant,
+			InactiveBefore: cutoff,
+		})
+		return counted.Chats, counted.Messages, err
+	}
+	batchSize := max(p.cfg.PurgeBatchSize, 1)
+	var chats, messages int64
+	for {
+		purged, err := p.queries.PurgeInactiveChats(ctx, database.PurgeInactiveChatsParams{
+			Tenant:         tenant,
+			InactiveBefore: cutoff,
+			BatchSize:      batchSize,
+		})
+		chats += purged.Chats
+		messages += purged.Messages
+		if err != nil || purged.Chats < int64(batchSize) || p.stopped() {
+			return chats, messages, err
+		}
+	}
+}
+
+// expireMessages removes the messages of the chats of the tenant that were created before the cutoff with their
+// tool call records in batches. The chats are kept with the rest of their conversation.
+func (p *ChatPurger) expireMessages(ctx context.Context, tenant string, cutoff time.Time) (int64, error) {
+	if p.cfg.PurgeDryRun {
+		return p.queries.CountExpiredMessages(ctx, database.CountExpiredMessagesParams{
+			Tenant:        tenant,
+			CreatedBefore: cutoff,
+		})
+	}
+	batchSize := max(p.cfg.PurgeBatchSize, 1)
+	var expired int64
+	for {
+		batch, removed, err := p.expireBatch(ctx, tenant, cutoff, batchSize)
+		expired += removed
+		if err != nil || batch < int(batchSize) || p.stopped() {
+			return expired, err
+		}
+	}
+}
+
+// expireBatch removes a batch of expired messages and reports how many were selected and how many were removed with
+// their tool call records. Removing a message would remove the newer messages following it as well, so they are
+// detached in the same transaction first.
+func (p *ChatPurger) expireBatch(ctx context.Context, tenant string, cutoff time.Time, batchSize int32) (int, int64, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	qtx := p.queries.WithTx(tx)
+
+	ids, err := qtx.ListExpiredMessages(ctx, database.ListExpiredMessagesParams{
+		Tenant:        tenant,
+		CreatedBefore: cutoff,
+		BatchSize:     batchSize,
+	})
+	if err != nil || len(ids) == 0 {
+		return 0, 0, err
+	}
+	if err := qtx.DetachContinuations(ctx, ids); err != nil {
+		return 0, 0, fmt.Errorf("detach continuations: %w", err)
+	}
+	removed, err := qtx.DeleteMessagesWithAttachments(ctx, ids)
+	if err != nil {
+		return 0, 0, fmt.Errorf("delete messages: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return len(ids), removed, nil
+}
+
+// stopped reports whether the purger was closed
+func (p *ChatPurger) stopped() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// summaryInstruction asks the LLM to fold the dropped messages into the rolling summary
+const summaryInstruction = "You maintain a running summary of a support conversation between a user and an assistant. " +
+	"Update the existing summary with the new messages. Keep facts, device names, decisions and open questions, drop small talk. " +
+	"Answer with the summary only, in at most %d words."
+
+// ContextMessage is a stored message of a chat considered for the prompt
+type ContextMessage struct {
+	ID uuid.UUID
+	ChatMessage
+}
+
+// UsageOwner is the user whose quota the tokens of LLM calls made on behalf of their chat count against
+type UsageOwner struct {
+	Email  string
+	Tenant string
+}
+
+// ContextWindow builds the prompt from the chat history within the token budget of the model.
+// The oldest turns are dropped first. If summaries are enabled the dropped turns are
+// replaced by a rolling summary that is generated by the LLM and persisted per chat.
+type ContextWindow struct {
+	cfg       config.ContextConfig
+	tokenizer Tokenizer
+	queries   *database.Queries
+	llm       LLMProvider
+}
+
+// NewContextWindow creates the context window for the given configuration
+func NewContextWindow(cfg config.ContextConfig, tokenizer Tokenizer, queries *database.Queries, llm LLMProvider) *ContextWindow {
+	return &ContextWindow{cfg: cfg, tokenizer: tokenizer, queries: queries, llm: llm}
+}
+
+// Build returns the messages of the chat history that are sent to the LLM.
+// Leading system messages and the latest message are always kept. Injected messages,
+// e.g. retrieved documentation, are placed right before the latest message. The tokens used
+// to summarize are recorded for the owner.
+func (w *ContextWindow) Build(ctx context.Context, chatID uuid.UUID, owner UsageOwner, history []ContextMessage, injected ...ChatMessage) ([]ChatMessage, error) {
+	if w.cfg.MaxTokens <= 0 {
+		return inject(chatMessages(history), injected), nil
+	}
+
+	// System messages before the first turn set up the conversation and are never dropped
+	pinned := 0
+	for pinned < len(history) && history[pinned].Role == RoleSystem {
+		pinned++
+	}
+	prefix, turns := history[:pinned], history[pinned:]
+
+	budget := w.cfg.MaxTokens
+	for _, message := range prefix {
+		budget -= countMessageTokens(w.tokenizer, message.ChatMessage)
+	}
+	for _, message := range injected {
+		budget -= countMessageTokens(w.tokenizer, message)
+	}
+
+	// Messages up to the end of the stored summary are represented by the summary
+	start, summary := 0, ""
+	if w.cfg.SummaryEnabled {
+		budget -= w.cfg.SummaryMaxTokens + messageOverheadTokens
+		stored, err := w.queries.GetChatSummary(ctx, chatID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err == nil {
+			if i := indexOfMessage(turns, stored.LastMessageID); i >= 0 {
+				start, summary = i+1, stored.Content
+			}
+		}
+	}
+
+	cut := w.cut(turns, start, budget)
+	if cut > start && w.cfg.SummaryEnabled {
+		updated, err := w.summarize(ctx, chatID, owner, summary, turns[start:cut])
+		if err != nil {
+			// The prompt still fits, the dropped turns are just missing from the summary
+			log.Printf("Failed to summarize chat %s: %v", chatID, err)
+		} else {
+			summary = updated
+		}
+	}
+
+	messages := chatMessages(prefix)
+	if summary != "" {
+		messages = append(messages, ChatMessage{
+			Role:    RoleSystem,
+			Content: "Summary of the earlier conversation:\n" + summary,
+		})
+	}
+	return inject(append(messages, chatMessages(turns[cut:])...), injected), nil
+}
+
+// inject inserts the messages before the latest message of the conversation
+func inject(messages, injected []ChatMessage) []ChatMessage {
+	if len(injected) == 0 || len(messages) == 0 {
+		return append(messages, injected...)
+	}
+	last := messages[len(messages)-1]
+	result := append(messages[:len(messages)-1:len(messages)-1], injected...)
+	return append(result, last)
+}
+
+// cut returns the index of the oldest turn at or after start from which on all messages fit into the budget.
+// Turns start with a user message, so a reply is never sent without its question.
+func (w *ContextWindow) cut(turns []ContextMessage, start, budget int) int {
+	if len(turns) == 0 {
+		return 0
+	}
+
+	cut := len(turns) - 1
+	budget -= countMessageTokens(w.tokenizer, turns[cut].ChatMessage)
+	for i := cut - 1; i >= start; i-- {
+		budget -= countMessageTokens(w.tokenizer, turns[i].ChatMessage)
+		if budget < 0 {
+			break
+		}
+		if turns[i].Role == RoleUser {
+			cut = i
+		}
+	}
+	return max(cut, start)
+}
+
+// summarize folds the dropped turns into the previous summary and persists the result
+func (w *ContextWindow) summarize(ctx context.Context, chatID uuid.UUID, owner UsageOwner, previous string, dropped []ContextMessage) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("Existing summary:\n" + previous + "\n\n")
+	}
+	transcript.WriteString("New messages:\n")
+	for _, message := range dropped {
+		fmt.Fprintf(&transcript, "%s: %s\n", message.Role, message.Content)
+	}
+
+	// Words are shorter than tokens, aim for three words per four tokens
+	words := w.cfg.SummaryMaxTokens * 3 / 4
+	completion, err := w.llm.Complete(ctx, CompletionRequest{
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: fmt.Sprintf(summaryInstruction, words)},
+			{Role: RoleUser, Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The summary is not a message of the chat, its usage only counts against the quotas
+	now := time.Now().UTC()
+	_, err = w.queries.CreateTokenUsage(ctx, database.CreateTokenUsageParams{
+		ID:               uuid.New(),
+		ChatID:           uuid.NullUUID{UUID: chatID, Valid: true},
+		UserEmail:        owner.Email,
+		Tenant:           owner.Tenant,
+		Model:            completion.Model,
+		PromptTokens:     int32(completion.PromptTokens),
+		CompletionTokens: int32(completion.CompletionTokens),
+		CreatedAt:        now,
+	})
+	if err != nil {
+		log.Printf("Failed to record summary token usage for chat %s: %v", chatID, err)
+	}
+
+	_, err = w.queries.UpsertChatSummary(ctx, database.UpsertChatSummaryParams{
+		ChatID:        chatID,
+		Content:       completion.Content,
+		LastMessageID: dropped[len(dropped)-1].ID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+// indexOfMessage returns the position of the message with the given id, or -1
+func indexOfMessage(messages []ContextMessage, id uuid.UUID) int {
+	for i, message := range messages {
+		if message.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// chatMessages strips the ids from the context messages
+func chatMessages(messages []ContextMessage) []ChatMessage {
+	result := make([]ChatMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, message.ChatMessage)
+	}
+	return result
+}
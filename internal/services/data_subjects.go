@@ -0,0 +1,325 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Who asked for an erasure, as recorded in the data_erasures table
+const (
+	ErasureBySelf  = "self"
+	ErasureByAdmin = "admin"
+)
+
+// DataSubjects answers data subject requests: it exports everything stored about a user and erases it.
+// Request logs and audit events shipped to OpenSearch are not covered, they expire with the index.
+type DataSubjects struct {
+	cfg     config.PrivacyConfig
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewDataSubjects creates the service for data subject requests
+func NewDataSubjects(cfg config.PrivacyConfig, db *sql.DB, queries *database.Queries) *DataSubjects {
+	return &DataSubjects{cfg: cfg, db: db, queries: queries}
+}
+
+// subjectHash identifies a user in erasure records without keeping the email address. It is keyed with the
+// server-side secret, a plain hash of an address could be reversed by hashing candidate addresses. Without
+// a secret the records keep no identifier.
+func (d *DataSubjects) subjectHash(email string) string {
+	if d.cfg.SubjectKey == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(d.cfg.SubjectKey))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// subjectExport is the manifest.json of an export
+type subjectExport struct {
+	UserEmail  string    `json:"userEmail"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Chats      int       `json:"chats"`
+	Messages   int       `json:"messages"`
+	TokenUsage int       `json:"tokenUsage"`
+	Imports    int       `json:"imports"`
+}
+
+type exportedChat struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	LastActiveDate time.Time  `json:"lastActiveDate"`
+	Pinned         bool       `json:"pinned"`
+	Archived       bool       `json:"archived"`
+	DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+}
+
+type exportedConversation struct {
+	Chat     exportedChat      `json:"chat"`
+	Summary  *exportedSummary  `json:"summary,omitempty"`
+	Messages []exportedMessage `json:"messages"`
+}
+
+type exportedSummary struct {
+	Content       string    `json:"content"`
+	LastMessageID uuid.UUID `json:"lastMessageId"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type exportedMessage struct {
+	ID               uuid.UUID        `json:"id"`
+	ParentID         *uuid.UUID       `json:"parentId,omitempty"`
+	SenderType       string           `json:"senderType"`
+	Content          string           `json:"content"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	UpdatedAt        time.Time        `json:"updatedAt"`
+	Incomplete       bool             `json:"incomplete"`
+	Active           bool             `json:"active"`
+	Variant          int32            `json:"variant"`
+	PromptTemplateID *uuid.UUID       `json:"promptTemplateId,omitempty"`
+	Sources          []exportedSource `json:"sources,omitempty"`
+}
+
+type exportedSource struct {
+	DocumentID *uuid.UUID `json:"documentId,omitempty"`
+	Title      string     `json:"title"`
+	Excerpt    string     `json:"excerpt"`
+	Score      float64    `json:"score"`
+}
+
+type exportedUsage struct {
+	ChatID           *uuid.UUID `json:"chatId,omitempty"`
+	MessageID        *uuid.UUID `json:"messageId,omitempty"`
+	Tenant           string     `json:"tenant"`
+	Model            string     `json:"model"`
+	PromptTokens     int32      `json:"promptTokens"`
+	CompletionTokens int32      `json:"completionTokens"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+type exportedImport struct {
+	ChatID     uuid.UUID `json:"chatId"`
+	Source     string    `json:"source"`
+	ExternalID string    `json:"externalId"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+// Export writes a ZIP archive of everything stored about the user: chats.json lists the chats including archived
+// and deleted ones, chats/<id>.json holds a chat with all messages, branches and sources, usage.json the token usage,
+// imports.json where imported chats came from and manifest.json what the archive contains. Chats are loaded one at
+// a time while the archive is written.
+func (d *DataSubjects) Export(ctx context.Context, w io.Writer, email string) error {
+	archive := zip.NewWriter(w)
+	manifest := subjectExport{UserEmail: email, ExportedAt: time.Now().UTC()}
+
+	chats, err := d.queries.ListChatsByUserEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("fetch chats: %w", err)
+	}
+	exportedChats := make([]exportedChat, 0, len(chats))
+	for _, chat := range chats {
+		exportedChats = append(exportedChats, toExportedChat(chat))
+	}
+	if err := writeJSON(archive, "chats.json", exportedChats); err != nil {
+		return err
+	}
+	for _, chat := range exportedChats {
+		conversation, err := d.conversation(ctx, chat)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(archive, "chats/"+chat.ID.String()+".json", conversation); err != nil {
+			return err
+		}
+		manifest.Messages += len(conversation.Messages)
+	}
+	manifest.Chats = len(chats)
+
+	usage, err := d.queries.ListTokenUsageByUserEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("fetch token usage: %w", err)
+	}
+	exportedUsages := make([]exportedUsage, 0, len(usage))
+	for _, row := range usage {
+		exportedUsages = append(exportedUsages, exportedUsage{
+			ChatID:           nullUUID(row.ChatID),
+			MessageID:        nullUUID(row.MessageID),
+			Tenant:           row.Tenant,
+			Model:            row.Model,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			CreatedAt:        row.CreatedAt,
+		})
+	}
+	if err := writeJSON(archive, "usage.json", exportedUsages); err != nil {
+		return err
+	}
+	manifest.TokenUsage = len(usage)
+
+	imports, err := d.queries.ListChatImportsByUserEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("fetch chat imports: %w", err)
+	}
+	exportedImports := make([]exportedImport, 0, len(imports))
+	for _, row := range imports {
+		exportedImports = append(exportedImports, exportedImport{
+			ChatID:     row.ChatID,
+			Source:     row.Source,
+			ExternalID: row.ExternalID,
+			ImportedAt: row.ImportedAt,
+		})
+	}
+	if err := writeJSON(archive, "imports.json", exportedImports); err != nil {
+		return err
+	}
+	manifest.Imports = len(imports)
+
+	if err := writeJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// conversation loads a chat with its summary and all messages including inactive branches and tool call records
+func (d *DataSubjects) conversation(ctx context.Context, chat exportedChat) (exportedConversation, error) {
+	conversation := exportedConversation{Chat: chat, Messages: []exportedMessage{}}
+	summary, err := d.queries.GetChatSummary(ctx, chat.ID)
+	if err == nil {
+		conversation.Summary = &exportedSummary{Content: summary.Content, LastMessageID: summary.LastMessageID, UpdatedAt: summary.UpdatedAt}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return conversation, fmt.Errorf("fetch summary of chat %s: %w", chat.ID, err)
+	}
+
+	messages, err := d.queries.GetMessagesByChatID(ctx, chat.ID)
+	if err != nil {
+		return conversation, fmt.Errorf("fetch messages of chat %s: %w", chat.ID, err)
+	}
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	sources := make(map[uuid.UUID][]exportedSource)
+	if len(ids) > 0 {
+		rows, err := d.queries.GetMessageSources(ctx, ids)
+		if err != nil {
+			return conversation, fmt.Errorf("fetch sources of chat %s: %w", chat.ID, err)
+		}
+		for _, row := range rows {
+			sources[row.MessageID] = append(sources[row.MessageID], exportedSource{
+				DocumentID: nullUUID(row.DocumentID),
+				Title:      row.Title,
+				Excerpt:    row.Excerpt,
+				Score:      row.Score,
+			})
+		}
+	}
+	for _, message := range messages {
+		conversation.Messages = append(conversation.Messages, exportedMessage{
+			ID:               message.ID,
+			ParentID:         nullUUID(message.ParentID),
+			SenderType:       message.SenderType,
+			Content:          message.Content,
+			CreatedAt:        message.CreatedAt,
+			UpdatedAt:        message.UpdatedAt,
+			Incomplete:       message.Incomplete,
+			Active:           message.Active,
+			Variant:          message.Variant,
+			PromptTemplateID: nullUUID(message.PromptTemplateID),
+			Sources:          sources[message.ID],
+		})
+	}
+	return conversation, nil
+}
+
+// Erase deletes the chats of the user with everything attached to them, anonymizes the token usage so tenant
+// quotas stay correct and drops the rate limit buckets, all in one transaction. The returned erasure record
+// only identifies the user by the keyed subject hash.
+func (d *DataSubjects) Erase(ctx context.Context, email string, initiatedBy string) (database.DataErasure, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.DataErasure{}, err
+	}
+	defer tx.Rollback()
+	qtx := d.queries.WithTx(tx)
+
+	messages, err := qtx.CountMessagesByUserEmail(ctx, email)
+	if err != nil {
+		return database.DataErasure{}, fmt.Errorf("count messages: %w", err)
+	}
+	chats, err := qtx.DeleteChatsByUserEmail(ctx, email)
+	if err != nil {
+		return database.DataErasure{}, fmt.Errorf("delete chats: %w", err)
+	}
+	usage, err := qtx.AnonymizeTokenUsage(ctx, email)
+	if err != nil {
+		return database.DataErasure{}, fmt.Errorf("anonymize token usage: %w", err)
+	}
+	if _, err := qtx.DeleteRateLimitBucketsByUser(ctx, email); err != nil {
+		return database.DataErasure{}, fmt.Errorf("delete rate limit buckets: %w", err)
+	}
+	erasure, err := qtx.CreateDataErasure(ctx, database.CreateDataErasureParams{
+		ID:          uuid.New(),
+		SubjectHash: d.subjectHash(email),
+		InitiatedBy: initiatedBy,
+		Chats:       int32(chats),
+		Messages:    int32(messages),
+		TokenUsage:  int32(usage),
+		ErasedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return database.DataErasure{}, fmt.Errorf("record erasure: %w", err)
+	}
+	return erasure, tx.Commit()
+}
+
+func toExportedChat(chat database.Chat) exportedChat {
+	exported := exportedChat{
+		ID:             chat.ID,
+		Title:          chat.Title,
+		CreatedAt:      chat.CreatedAt,
+		UpdatedAt:      chat.UpdatedAt,
+		LastActiveDate: chat.LastActiveDate,
+		Pinned:         chat.Pinned,
+		Archived:       chat.Archived,
+	}
+	if chat.DeletedAt.Valid {
+		exported.DeletedAt = &chat.DeletedAt.Time
+	}
+	return exported
+}
+
+// writeJSON adds an indented JSON file to the archive
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func nullUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"ai-chat-service-go/internal/devices"
+)
+
+// DeviceTools lets the LLM look up the actual devices of the user instead of answering generically
+func DeviceTools(directory devices.Directory) []Tool {
+	return []Tool{
+		{
+			Name:        "list_devices",
+			Description: "Lists the devices registered to the user with their id, name, model, IP address, firmware version and online status.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+			Handler: func(ctx context.Context, user ToolUser, arguments json.RawMessage) (string, error) {
+				list, err := directory.List(ctx, user.Email)
+				if err != nil {
+					return "", err
+				}
+				return toolOutput(list)
+			},
+		},
+		{
+			Name:        "get_device",
+			Description: "Returns the details of one device of the user, e.g. its IP address or firmware version. The device is identified by its id, serial number or name.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"device": {"type": "string", "minLength": 1, "description": "Id, serial number or name of the device"}
+				},
+				"required": ["device"]
+			}`),
+			Handler: func(ctx context.Context, user ToolUser, arguments json.RawMessage) (string, error) {
+				var args struct {
+					Device string `json:"device"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", err
+				}
+				device, err := directory.Get(ctx, user.Email, args.Device)
+				if errors.Is(err, devices.ErrDeviceNotFound) {
+					return "No device " + args.Device + " is registered to the user.", nil
+				}
+				if err != nil {
+					return "", err
+				}
+				return toolOutput(device)
+			},
+		},
+	}
+}
+
+// toolOutput encodes a tool result as JSON for the LLM
+func toolOutput(value any) (string, error) {
+	output, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned when a generation is started after draining began
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// Generations tracks in-flight LLM generations so shutdown can wait for them to finish.
+// Generations run on their own context rather than the request's, which the HTTP
+// server cancels as soon as shutdown begins.
+type Generations struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// NewGenerations creates an empty generation tracker
+func NewGenerations() *Generations {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Generations{ctx: ctx, cancel: cancel}
+}
+
+// Start registers a new generation. The returned context is cancelled when the
+// drain deadline is exceeded, done must be called once the generation is persisted.
+func (g *Generations) Start() (ctx context.Context, done func(), err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.draining {
+		return nil, nil, ErrShuttingDown
+	}
+	g.wg.Add(1)
+	return g.ctx, g.wg.Done, nil
+}
+
+// Drain refuses new generations and waits for the in-flight ones until ctx expires.
+// Generations still running at the deadline are cancelled and given the chance
+// to persist their partial results before Drain returns.
+func (g *Generations) Drain(ctx context.Context) error {
+	g.mu.Lock()
+	g.draining = true
+	g.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		<-finished
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-chat-service-go/internal/config"
+)
+
+// Roles of the messages sent to the LLM
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ChatMessage is a single message of the conversation sent to the LLM
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls are the tools an assistant message requested
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a tool message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a request of the LLM to call a tool
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the tool and its arguments as JSON object
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolDefinition describes a tool to the LLM
+type ToolDefinition struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments
+	Parameters json.RawMessage
+}
+
+// CompletionRequest is the conversation the LLM should answer
+type CompletionRequest struct {
+	Messages []ChatMessage
+	// Tools the LLM may call instead of answering
+	Tools []ToolDefinition
+	// DisableTools forces an answer, the tools are still described for the calls in the conversation
+	DisableTools bool
+	// OnDelta, if set, streams the answer and is called with every generated chunk
+	OnDelta func(delta string)
+}
+
+// Completion is the answer generated by the LLM, or the tools it wants to call
+type Completion struct {
+	Content          string
+	ToolCalls        []ToolCall
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLMProvider generates answers for a conversation
+type LLMProvider interface {
+	// Name identifies the provider, e.g. in health checks
+	Name() string
+	// Complete generates the next assistant message for the conversation.
+	// If generation is interrupted the content produced so far is returned along with the error.
+	Complete(ctx context.Context, req CompletionRequest) (Completion, error)
+	// Ping checks that the provider is reachable
+	Ping(ctx context.Context) error
+}
+
+// NewLLMProvider creates the provider selected in the configuration
+func NewLLMProvider(cfg config.LLMConfig) (LLMProvider, error) {
+	switch cfg.Provider {
+	case "", "mock":
+		return &MockProvider{}, nil
+	case "openai":
+		return NewOpenAIProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// MockProvider answers with canned responses, see GenerateAIResponse
+type MockProvider struct{}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) Complete(ctx context.Context, req CompletionRequest) (Completion, error) {
+	// The mock only looks at the latest user message
+	var last string
+	for _, message := range req.Messages {
+		if message.Role == RoleUser {
+			last = message.Content
+		}
+	}
+
+	// Questions about devices are answered with the list_devices tool if it is available
+	if call, ok := mockToolCall(req, last); ok {
+		return Completion{ToolCalls: []ToolCall{call}, Model: "mock"}, nil
+	}
+
+	content, err := GenerateAIResponse(last)
+	if err != nil {
+		return Completion{}, err
+	}
+	if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == RoleTool {
+		content = "Here is what I found about your devices:\n\n" + req.Messages[n-1].Content
+	}
+	if req.OnDelta != nil {
+		req.OnDelta(content)
+	}
+
+	// The mock has no tokenizer, estimate roughly four characters per token
+	promptTokens := 0
+	for _, message := range req.Messages {
+		promptTokens += len(message.Content) / 4
+	}
+	return Completion{
+		Content:          content,
+		Model:            "mock",
+		PromptTokens:     promptTokens,
+		CompletionTokens: len(content) / 4,
+	}, nil
+}
+
+func (p *MockProvider) Ping(ctx context.Context) error {
+	return nil
+}
+
+// mockToolCall requests the device list once for a user message mentioning devices
+func mockToolCall(req CompletionRequest, userMessage string) (ToolCall, bool) {
+	if req.DisableTools || len(req.Messages) == 0 || req.Messages[len(req.Messages)-1].Role != RoleUser {
+		return ToolCall{}, false
+	}
+	if !strings.Contains(strings.ToLower(userMessage), "device") {
+		return ToolCall{}, false
+	}
+	for _, tool := range req.Tools {
+		if tool.Name == "list_devices" {
+			return ToolCall{
+				ID:       "call_mock",
+				Type:     "function",
+				Function: ToolCallFunction{Name: tool.Name, Arguments: "{}"},
+			}, true
+		}
+	}
+	return ToolCall{}, false
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"ai-chat-service-go/internal/mcp"
+)
+
+// MCPTools offers the tools discovered on an MCP server to the LLM. The tools are named
+// <server>__<tool> so tools of different servers don't clash, allowed decides per role.
+func MCPTools(client *mcp.Client, allowed func(roles []string, tool string) bool) []Tool {
+	tools := make([]Tool, 0, len(client.Tools()))
+	for _, serverTool := range client.Tools() {
+		name := client.Name() + "__" + serverTool.Name
+		tools = append(tools, Tool{
+			Name:        name,
+			Description: serverTool.Description,
+			Parameters:  serverTool.InputSchema,
+			Handler: func(ctx context.Context, user ToolUser, arguments json.RawMessage) (string, error) {
+				result, err := client.CallTool(ctx, serverTool.Name, arguments)
+				if err != nil {
+					return "", err
+				}
+				if result.IsError {
+					return "", errors.New(result.Text())
+				}
+				return result.Text(), nil
+			},
+			Allowed: func(user ToolUser) bool {
+				return allowed(user.Roles, name)
+			},
+		})
+	}
+	return tools
+}
@@ -0,0 +1,84 @@
+package services
+
+import "sync"
+
+// subscriberBuffer is the number of events queued per connected client before events are dropped
+const subscriberBuffer = 16
+
+// Event is pushed to the connected clients of a user, Data is encoded as JSON
+type Event struct {
+	Type string
+	Data any
+}
+
+// Notifier fans out events to the event streams of the connected clients of a user.
+// Delivery is best effort: events for clients that don't keep up are dropped.
+type Notifier struct {
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewNotifier creates a notifier without subscribers
+func NewNotifier() *Notifier {
+	return &Notifier{subscribers: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe registers a client of the user. The channel is closed by unsubscribe or when the notifier is closed.
+func (n *Notifier) Subscribe(email string) (events <-chan Event, unsubscribe func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	if n.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if n.subscribers[email] == nil {
+		n.subscribers[email] = make(map[chan Event]struct{})
+	}
+	n.subscribers[email][ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if _, ok := n.subscribers[email][ch]; !ok {
+				return
+			}
+			delete(n.subscribers[email], ch)
+			if len(n.subscribers[email]) == 0 {
+				delete(n.subscribers, email)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Publish sends the event to every connected client of the user
+func (n *Notifier) Publish(email string, event Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.subscribers[email] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Close ends all event streams, so open connections don't hold up the shutdown
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed = true
+	for email, subscribers := range n.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(n.subscribers, email)
+	}
+}
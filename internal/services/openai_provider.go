@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ai-chat-service-go/internal/config"
+)
+
+// OpenAIProvider talks to an OpenAI compatible chat completions API
+type OpenAIProvider struct {
+	cfg    config.LLMConfig
+	client *http.Client
+}
+
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []ChatMessage        `json:"messages"`
+	Tools         []openAITool         `json:"tools,omitempty"`
+	ToolChoice    string               `json:"tool_choice,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message ChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage openAIUsage `json:"usage"`
+}
+
+type openAIChatChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta openAIDelta `json:"delta"`
+	} `json:"choices"`
+	// Usage is only set on the last chunk if requested via stream options
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIDelta struct {
+	Content   string                `json:"content"`
+	ToolCalls []openAIToolCallDelta `json:"tool_calls"`
+}
+
+// openAIToolCallDelta is a fragment of a streamed tool call, fragments with the same index belong together
+type openAIToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewOpenAIProvider creates a provider for an OpenAI compatible API
+func NewOpenAIProvider(cfg config.LLMConfig) *OpenAIProvider {
+	return &OpenAIProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (Completion, error) {
+	request := openAIChatRequest{
+		Model:    p.cfg.Model,
+		Messages: req.Messages,
+	}
+	for _, tool := range req.Tools {
+		request.Tools = append(request.Tools, openAITool{
+			Type:     "function",
+			Function: openAIToolFunction{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters},
+		})
+	}
+	if req.DisableTools && len(request.Tools) > 0 {
+		request.ToolChoice = "none"
+	}
+	if req.OnDelta != nil {
+		request.Stream = true
+		request.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	resp, err := p.do(ctx, http.MethodPost, "/chat/completions", payload)
+	if err != nil {
+		return Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	if req.OnDelta != nil {
+		return p.readStream(resp.Body, req.OnDelta)
+	}
+
+	var result openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Completion{}, fmt.Errorf("decoding chat completion: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return Completion{}, fmt.Errorf("chat completion returned no choices")
+	}
+
+	return Completion{
+		Content:          result.Choices[0].Message.Content,
+		ToolCalls:        result.Choices[0].Message.ToolCalls,
+		Model:            result.Model,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}, nil
+}
+
+// readStream reads server-sent chat completion chunks until the stream ends or breaks off
+func (p *OpenAIProvider) readStream(body io.Reader, onDelta func(string)) (Completion, error) {
+	var completion Completion
+	var content strings.Builder
+	var toolCalls []ToolCall
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			completion.Content = content.String()
+			completion.ToolCalls = toolCalls
+			return completion, nil
+		}
+
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			completion.Content = content.String()
+			return completion, fmt.Errorf("decoding chat completion chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			completion.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completion.PromptTokens = chunk.Usage.PromptTokens
+			completion.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+			for _, delta := range choice.Delta.ToolCalls {
+				if delta.Index < 0 {
+					continue
+				}
+				for len(toolCalls) <= delta.Index {
+					toolCalls = append(toolCalls, ToolCall{Type: "function"})
+				}
+				call := &toolCalls[delta.Index]
+				if delta.ID != "" {
+					call.ID = delta.ID
+				}
+				call.Function.Name += delta.Function.Name
+				call.Function.Arguments += delta.Function.Arguments
+			}
+		}
+	}
+
+	completion.Content = content.String()
+	if err := scanner.Err(); err != nil {
+		return completion, err
+	}
+	return completion, io.ErrUnexpectedEOF
+}
+
+func (p *OpenAIProvider) Ping(ctx context.Context) error {
+	resp, err := p.do(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends an authenticated request and turns non-2xx responses into errors
+func (p *OpenAIProvider) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	url := strings.TrimRight(p.cfg.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr openAIErrorResponse
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("%s %s failed with status %d: %s", method, path, resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("%s %s failed with status %d", method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// promptFileExtension is the extension of the template files in the prompt directory
+const promptFileExtension = ".tmpl"
+
+// ErrPromptNotFound is returned when no template with the requested name exists
+var ErrPromptNotFound = errors.New("prompt template not found")
+
+// PromptVariables are the values available in system prompt templates, e.g. {{.UserName}}
+type PromptVariables struct {
+	UserName string
+	Email    string
+	Locale   string
+	Tenant   string
+	// Date is the current date formatted as YYYY-MM-DD
+	Date string
+	Now  time.Time
+}
+
+// RenderedPrompt is a system prompt along with the template version it was rendered from
+type RenderedPrompt struct {
+	Template database.PromptTemplate
+	Content  string
+}
+
+// Prompts renders the system prompt templates. Templates are stored in the database,
+// templates loaded from files are added as a new version whenever the file changes.
+type Prompts struct {
+	cfg     config.PromptConfig
+	queries *database.Queries
+
+	// Versions never change, so parsed templates are cached by id
+	mu     sync.RWMutex
+	parsed map[uuid.UUID]*template.Template
+}
+
+// NewPrompts creates the prompt templates for the given configuration
+func NewPrompts(cfg config.PromptConfig, queries *database.Queries) *Prompts {
+	return &Prompts{
+		cfg:     cfg,
+		queries: queries,
+		parsed:  make(map[uuid.UUID]*template.Template),
+	}
+}
+
+// LoadFiles stores every template file of the prompt directory whose content differs from the latest version.
+// Only unreadable or invalid template files are returned as error.
+func (p *Prompts) LoadFiles(ctx context.Context) error {
+	if p.cfg.Dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(p.cfg.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != promptFileExtension {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), promptFileExtension)
+		content, err := os.ReadFile(filepath.Join(p.cfg.Dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if _, err := template.New(name).Parse(string(content)); err != nil {
+			return fmt.Errorf("prompt template %s: %w", entry.Name(), err)
+		}
+
+		// The database may not be up or migrated yet, the versions stored before remain in use
+		latest, err := p.queries.GetLatestPromptTemplate(ctx, name)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load prompt template %s: %v", name, err)
+			continue
+		}
+		if err == nil && latest.Content == string(content) {
+			continue
+		}
+
+		created, err := p.queries.CreatePromptTemplateVersion(ctx, database.CreatePromptTemplateVersionParams{
+			ID:        uuid.New(),
+			Name:      name,
+			Content:   string(content),
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			// Another replica starting at the same time may have added the version already
+			log.Printf("Failed to store prompt template %s: %v", name, err)
+			continue
+		}
+		log.Printf("Stored prompt template %s version %d", created.Name, created.Version)
+	}
+	return nil
+}
+
+// DefaultName returns the name of the template used for chats that don't select one
+func (p *Prompts) DefaultName() string {
+	return p.cfg.Default
+}
+
+// DefaultLocale returns the locale used if the client doesn't send one
+func (p *Prompts) DefaultLocale() string {
+	return p.cfg.DefaultLocale
+}
+
+// Render renders the latest version of the template with the given name
+func (p *Prompts) Render(ctx context.Context, name string, vars PromptVariables) (RenderedPrompt, error) {
+	latest, err := p.queries.GetLatestPromptTemplate(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RenderedPrompt{}, ErrPromptNotFound
+	}
+	if err != nil {
+		return RenderedPrompt{}, err
+	}
+
+	tmpl, err := p.parse(latest)
+	if err != nil {
+		return RenderedPrompt{}, err
+	}
+	var content strings.Builder
+	if err := tmpl.Execute(&content, vars); err != nil {
+		return RenderedPrompt{}, fmt.Errorf("rendering prompt template %s version %d: %w", latest.Name, latest.Version, err)
+	}
+	return RenderedPrompt{Template: latest, Content: strings.TrimSpace(content.String())}, nil
+}
+
+// Get returns the template version with the given id
+func (p *Prompts) Get(ctx context.Context, id uuid.UUID) (database.PromptTemplate, error) {
+	return p.queries.GetPromptTemplate(ctx, id)
+}
+
+// List returns the latest version of every template
+func (p *Prompts) List(ctx context.Context) ([]database.PromptTemplate, error) {
+	return p.queries.ListLatestPromptTemplates(ctx)
+}
+
+// parse returns the parsed template of a version, templates stored in the database are parsed on first use
+func (p *Prompts) parse(version database.PromptTemplate) (*template.Template, error) {
+	p.mu.RLock()
+	tmpl, ok := p.parsed[version.ID]
+	p.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := template.New(version.Name).Parse(version.Content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt template %s version %d: %w", version.Name, version.Version, err)
+	}
+	p.mu.Lock()
+	p.parsed[version.ID] = tmpl
+	p.mu.Unlock()
+	return tmpl, nil
+}
+
+// NewPromptVariables collects the template variables for a user
+func NewPromptVariables(name, email, locale, tenant string) PromptVariables {
+	now := time.Now().UTC()
+	return PromptVariables{
+		UserName: name,
+		Email:    email,
+		Locale:   locale,
+		Tenant:   tenant,
+		Date:     now.Format(time.DateOnly),
+		Now:      now,
+	}
+}
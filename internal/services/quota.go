@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+)
+
+// ErrQuotaExceeded is returned when the monthly token quota of a user or their tenant is used up
+var ErrQuotaExceeded = errors.New("monthly token quota exceeded")
+
+// QuotaStatus is the token consumption of a user in the current month
+type QuotaStatus struct {
+	// Limit is the monthly token quota of the user, 0 means unlimited
+	Limit int64
+	// Used is the number of tokens consumed by the user this month
+	Used int64
+	// TenantLimit is the monthly token quota of the tenant, 0 means unlimited
+	TenantLimit int64
+	// TenantUsed is the number of tokens consumed by the whole tenant this month
+	TenantUsed int64
+	// ResetsAt is the start of the next month, when the quota resets
+	ResetsAt time.Time
+}
+
+// Exceeded reports whether the user or the tenant used up their quota
+func (s QuotaStatus) Exceeded() bool {
+	return (s.Limit > 0 && s.Used >= s.Limit) || (s.TenantLimit > 0 && s.TenantUsed >= s.TenantLimit)
+}
+
+// Quotas enforces the monthly token quotas per role and tenant
+type Quotas struct {
+	cfg     config.QuotaConfig
+	queries *database.Queries
+}
+
+// NewQuotas creates the quota enforcement for the given configuration
+func NewQuotas(cfg config.QuotaConfig, queries *database.Queries) *Quotas {
+	return &Quotas{cfg: cfg, queries: queries}
+}
+
+// Status returns the quota and consumption of a user in the current month
+func (q *Quotas) Status(ctx context.Context, email, tenant string, roles []string) (QuotaStatus, error) {
+	monthStart := MonthStart(time.Now())
+	status := QuotaStatus{
+		Limit:       q.userLimit(roles),
+		TenantLimit: q.cfg.TenantMonthlyTokens[tenant],
+		ResetsAt:    monthStart.AddDate(0, 1, 0),
+	}
+
+	used, err := q.queries.GetUserTokensSince(ctx, database.GetUserTokensSinceParams{
+		UserEmail: email,
+		CreatedAt: monthStart,
+	})
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	status.Used = used
+
+	if status.TenantLimit > 0 {
+		tenantUsed, err := q.queries.GetTenantTokensSince(ctx, database.GetTenantTokensSinceParams{
+			Tenant:    tenant,
+			CreatedAt: monthStart,
+		})
+		if err != nil {
+			return QuotaStatus{}, err
+		}
+		status.TenantUsed = tenantUsed
+	}
+
+	return status, nil
+}
+
+// Check returns ErrQuotaExceeded along with the status if the user may not consume more tokens this month
+func (q *Quotas) Check(ctx context.Context, email, tenant string, roles []string) (QuotaStatus, error) {
+	status, err := q.Status(ctx, email, tenant, roles)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	if status.Exceeded() {
+		return status, ErrQuotaExceeded
+	}
+	return status, nil
+}
+
+// userLimit returns the most generous quota of the user's roles, or the default quota
+func (q *Quotas) userLimit(roles []string) int64 {
+	limit, found := int64(0), false
+	for _, role := range roles {
+		roleLimit, ok := q.cfg.RoleMonthlyTokens[role]
+		if !ok {
+			continue
+		}
+		if roleLimit == 0 {
+			// One unlimited role is enough
+			return 0
+		}
+		if !found || roleLimit > limit {
+			limit, found = roleLimit, true
+		}
+	}
+	if found {
+		return limit
+	}
+	return q.cfg.DefaultMonthlyTokens
+}
+
+// MonthStart returns the start of the month of t in UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-chat-service-go/internal/config"
+	"ai-chat-service-go/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// titleInstruction asks the LLM for a short title of the first exchange of a chat
+const titleInstruction = "Write a title for the support conversation below in at most %d words, in the language of the user. " +
+	"Answer with the title only, without quotes or trailing punctuation."
+
+// ErrEmptyTitle is returned if the LLM answered without a usable title
+var ErrEmptyTitle = errors.New("the LLM generated an empty title")
+
+// Titles generates short chat titles with the LLM
+type Titles struct {
+	cfg     config.ChatsConfig
+	queries *database.Queries
+	llm     LLMProvider
+}
+
+// NewTitles creates the title generator
+func NewTitles(cfg config.ChatsConfig, queries *database.Queries, llm LLMProvider) *Titles {
+	return &Titles{cfg: cfg, queries: queries, llm: llm}
+}
+
+// Generate asks the LLM for a title of the chat made of the question and the answer to it.
+// The tokens used are recorded for the owner.
+func (t *Titles) Generate(ctx context.Context, chatID uuid.UUID, owner UsageOwner, question, answer string) (string, error) {
+	completion, err := t.llm.Complete(ctx, CompletionRequest{
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: fmt.Sprintf(titleInstruction, t.cfg.TitleMaxWords)},
+			{Role: RoleUser, Content: fmt.Sprintf("user: %s\nassistant: %s", question, answer)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The title is not a message of the chat, its usage only counts against the quotas
+	_, err = t.queries.CreateTokenUsage(ctx, database.CreateTokenUsageParams{
+		ID:               uuid.New(),
+		ChatID:           uuid.NullUUID{UUID: chatID, Valid: true},
+		UserEmail:        owner.Email,
+		Tenant:           owner.Tenant,
+		Model:            completion.Model,
+		PromptTokens:     int32(completion.PromptTokens),
+		CompletionTokens: int32(completion.CompletionTokens),
+		CreatedAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Failed to record title token usage for chat %s: %v", chatID, err)
+	}
+
+	title := cleanTitle(completion.Content)
+	if title == "" {
+		return "", ErrEmptyTitle
+	}
+	return title, nil
+}
+
+// cleanTitle strips what LLMs like to add around a title: a "Title:" prefix, quotes, markdown and a final period
+func cleanTitle(content string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	title = strings.TrimSpace(title)
+	if prefix, rest, ok := strings.Cut(title, ":"); ok && strings.EqualFold(strings.TrimSpace(prefix), "title") {
+		title = rest
+	}
+	title = strings.Trim(title, " \t*#_`\"'“”„«»")
+	return strings.TrimRight(title, " .")
+}
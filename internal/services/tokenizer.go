@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// messageOverheadTokens approximates the tokens a message costs on top of its content, e.g. for the role
+const messageOverheadTokens = 4
+
+// Tokenizer estimates how many tokens a text costs in the prompt.
+// Estimates only need to be close enough to keep the prompt within the context window.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// NewTokenizer creates the tokenizer selected in the configuration
+func NewTokenizer(name string) (Tokenizer, error) {
+	switch name {
+	case "", "chars":
+		return CharTokenizer{CharsPerToken: 4}, nil
+	case "words":
+		return WordTokenizer{TokensPerWord: 4.0 / 3}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q", name)
+	}
+}
+
+// CharTokenizer estimates tokens from the number of characters, roughly four per token for English text
+type CharTokenizer struct {
+	CharsPerToken float64
+}
+
+func (t CharTokenizer) CountTokens(text string) int {
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / t.CharsPerToken))
+}
+
+// WordTokenizer estimates tokens from the number of words, roughly three words per four tokens
+type WordTokenizer struct {
+	TokensPerWord float64
+}
+
+func (t WordTokenizer) CountTokens(text string) int {
+	return int(math.Ceil(float64(len(strings.Fields(text))) * t.TokensPerWord))
+}
+
+// countMessageTokens estimates the tokens of a message including its overhead
+func countMessageTokens(tokenizer Tokenizer, message ChatMessage) int {
+	return tokenizer.CountTokens(message.Content) + messageOverheadTokens
+}
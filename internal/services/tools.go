@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"ai-chat-service-go/internal/config"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// toolNamePattern is the tool name format accepted by the LLM APIs
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolUser is the user on whose behalf a tool is called
+type ToolUser struct {
+	Email  string
+	Tenant string
+	Roles  []string
+}
+
+// ToolHandler executes a tool call. The arguments are validated against the parameters of the tool,
+// the returned output is passed to the LLM as is.
+type ToolHandler func(ctx context.Context, user ToolUser, arguments json.RawMessage) (string, error)
+
+// Tool is a function the LLM can call while answering
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments object
+	Parameters json.RawMessage
+	Handler    ToolHandler
+	// Allowed restricts the tool to some users, nil offers it to every user
+	Allowed func(user ToolUser) bool
+}
+
+// ToolResult is the outcome of a tool call, Err is set if the call failed
+type ToolResult struct {
+	Call   ToolCall
+	Output string
+	Err    error
+}
+
+type registeredTool struct {
+	Tool
+	schema *openapi3.Schema
+}
+
+// ToolRegistry holds the tools offered to the LLM and runs the tool-call loop
+type ToolRegistry struct {
+	cfg   config.ToolsConfig
+	tools map[string]registeredTool
+	names []string
+}
+
+// NewToolRegistry creates an empty registry
+func NewToolRegistry(cfg config.ToolsConfig) *ToolRegistry {
+	return &ToolRegistry{cfg: cfg, tools: make(map[string]registeredTool)}
+}
+
+// Register adds a tool, names must be unique
+func (r *ToolRegistry) Register(tool Tool) error {
+	if !toolNamePattern.MatchString(tool.Name) {
+		return fmt.Errorf("invalid tool name %q", tool.Name)
+	}
+	if _, ok := r.tools[tool.Name]; ok {
+		return fmt.Errorf("tool %q is already registered", tool.Name)
+	}
+	if len(tool.Parameters) == 0 {
+		tool.Parameters = json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	schema := &openapi3.Schema{}
+	if err := json.Unmarshal(tool.Parameters, schema); err != nil {
+		return fmt.Errorf("parameters of tool %q: %w", tool.Name, err)
+	}
+
+	r.tools[tool.Name] = registeredTool{Tool: tool, schema: schema}
+	r.names = append(r.names, tool.Name)
+	return nil
+}
+
+// Definitions describes the tools the user may use to the LLM
+func (r *ToolRegistry) Definitions(user ToolUser) []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(r.names))
+	for _, name := range r.names {
+		tool := r.tools[name]
+		if !tool.allows(user) {
+			continue
+		}
+		definitions = append(definitions, ToolDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  tool.Parameters,
+		})
+	}
+	return definitions
+}
+
+// Complete generates the answer while letting the LLM call tools: requested tools are executed and their
+// results fed back until the LLM answers. After MaxIterations rounds of tool calls the LLM has to answer.
+// onCall is called with every executed tool call. The token usage of all rounds is summed up.
+func (r *ToolRegistry) Complete(ctx context.Context, llm LLMProvider, user ToolUser, req CompletionRequest, onCall func(ToolResult)) (Completion, error) {
+	req.Tools = r.Definitions(user)
+	if len(req.Tools) == 0 {
+		return llm.Complete(ctx, req)
+	}
+
+	req.Messages = append([]ChatMessage(nil), req.Messages...)
+	promptTokens, completionTokens := 0, 0
+	for iteration := 0; ; iteration++ {
+		req.DisableTools = iteration >= r.cfg.MaxIterations
+		completion, err := llm.Complete(ctx, req)
+		promptTokens += completion.PromptTokens
+		completionTokens += completion.CompletionTokens
+		completion.PromptTokens, completion.CompletionTokens = promptTokens, completionTokens
+		if err != nil || len(completion.ToolCalls) == 0 || req.DisableTools {
+			completion.ToolCalls = nil
+			return completion, err
+		}
+
+		req.Messages = append(req.Messages, ChatMessage{
+			Role:      RoleAssistant,
+			Content:   completion.Content,
+			ToolCalls: completion.ToolCalls,
+		})
+		for _, call := range completion.ToolCalls {
+			result := r.call(ctx, user, call)
+			if onCall != nil {
+				onCall(result)
+			}
+			output := result.Output
+			if result.Err != nil {
+				// The LLM sees the error and can correct the arguments or answer without the tool
+				output = "error: " + result.Err.Error()
+			}
+			req.Messages = append(req.Messages, ChatMessage{Role: RoleTool, Content: output, ToolCallID: call.ID})
+		}
+	}
+}
+
+// call validates the arguments and executes a single tool call
+func (r *ToolRegistry) call(ctx context.Context, user ToolUser, call ToolCall) ToolResult {
+	// A tool the user may not use is treated as unknown, the LLM could request it anyway
+	tool, ok := r.tools[call.Function.Name]
+	if !ok || !tool.allows(user) {
+		return ToolResult{Call: call, Err: fmt.Errorf("unknown tool %q", call.Function.Name)}
+	}
+
+	arguments := json.RawMessage(call.Function.Arguments)
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	var value any
+	if err := json.Unmarshal(arguments, &value); err != nil {
+		return ToolResult{Call: call, Err: fmt.Errorf("arguments are not valid JSON: %w", err)}
+	}
+	if err := tool.schema.VisitJSON(value); err != nil {
+		// The full schema error repeats the schema and value, the reason is enough for the LLM
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err, &schemaErr) {
+			return ToolResult{Call: call, Err: fmt.Errorf("invalid arguments: %s", schemaErr.Reason)}
+		}
+		return ToolResult{Call: call, Err: fmt.Errorf("invalid arguments: %w", err)}
+	}
+
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+	output, err := tool.Handler(ctx, user, arguments)
+	return ToolResult{Call: call, Output: output, Err: err}
+}
+
+func (t registeredTool) allows(user ToolUser) bool {
+	return t.Allowed == nil || t.Allowed(user)
+}
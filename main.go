@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "ai-chat-service-go/docs"
 	"ai-chat-service-go/internal/api"
 	"ai-chat-service-go/internal/config"
 	"ai-chat-service-go/internal/database"
+	"ai-chat-service-go/internal/devices"
 	"ai-chat-service-go/internal/errors"
+	"ai-chat-service-go/internal/health"
+	"ai-chat-service-go/internal/importer"
+	"ai-chat-service-go/internal/knowledge"
+	"ai-chat-service-go/internal/mcp"
+	"ai-chat-service-go/internal/middleware"
+	"ai-chat-service-go/internal/opensearch"
+	"ai-chat-service-go/internal/ratelimit"
+	"ai-chat-service-go/internal/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/swagger"
 
 	_ "github.com/lib/pq"
@@ -24,6 +39,11 @@ import (
 type ChatServer struct{}
 
 func main() {
+	// "main import <archive.json>" imports chats instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -40,6 +60,19 @@ func main() {
 	// connection is up, possible to do querries here
 	queries := database.New(dbConn)
 
+	// Create the LLM provider answering chat messages
+	llm, err := services.NewLLMProvider(cfg.LLM)
+	if err != nil {
+		log.Fatalf("Failed to create LLM provider: %v", err)
+	}
+
+	// Ship request logs and audit events to OpenSearch if enabled
+	var events *opensearch.Sink
+	if cfg.OpenSearch.Enabled {
+		events = opensearch.NewSink(cfg.OpenSearch)
+		log.Printf("Shipping logs and audit events to OpenSearch index %s", cfg.OpenSearch.Index)
+	}
+
 	// Create a new Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: errors.ErrorHandler,
@@ -47,7 +80,19 @@ func main() {
 
 	// Setup middleware
 	app.Use(recover.New())
+	app.Use(requestid.New())
+
+	// Health endpoints are registered before the logging middleware to keep probes out of the logs
+	checker := health.NewChecker(cfg.Health.CheckTimeout,
+		health.DatabaseCheck(dbConn),
+		health.MigrationCheck(dbConn, cfg.Database.MigrationDir),
+		health.LLMCheck(llm),
+	)
+	app.Get("/healthz", checker.Liveness)
+	app.Get("/readyz", checker.Readiness)
+
 	app.Use(logger.New())
+	app.Use(middleware.RequestLogger(events))
 
 	// Configure CORS
 	corsConfig := cors.Config{
@@ -63,6 +108,19 @@ func main() {
 	}
 	app.Use(cors.New(corsConfig))
 
+	// Every API request needs a Keycloak token, the mock user is only for local development
+	if cfg.Auth.MockEnabled {
+		log.Printf("WARNING: authentication is disabled, every request acts as %s with the roles %v", cfg.Auth.MockEmail, cfg.Auth.MockRoles)
+		app.Use("/v1", middleware.MockAuth(middleware.MockUserInfo(cfg.Auth.MockEmail, cfg.Auth.MockRoles)))
+	} else {
+		auth, err := middleware.Auth(cfg.Auth)
+		if err != nil {
+			log.Fatalf("Failed to create authentication middleware: %v", err)
+		}
+		app.Use("/v1", auth)
+	}
+	app.Use("/v1/admin", middleware.RequireRoles("admin"))
+
 	// serving the static file is needed in order to serve the Swagger UI
 	app.Static("/api.yml", "./api.yml") // Serve your api.yml file here
 
@@ -71,13 +129,208 @@ func main() {
 		URL: "/api.yml",
 	}))
 
+	// Validate requests against the OpenAPI spec
+	if cfg.Validation.Enabled {
+		validator, err := middleware.RequestValidator(cfg.Validation)
+		if err != nil {
+			log.Fatalf("Failed to create request validator: %v", err)
+		}
+		app.Use(validator)
+	}
+
+	// Rate limit chat and message creation per user
+	var rateLimitStore ratelimit.Store
+	messageRate := ratelimit.PerMinute(cfg.RateLimit.MessagesPerMinute, cfg.RateLimit.MessageBurst)
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store
+		switch cfg.RateLimit.Store {
+		case "memory":
+			store = ratelimit.NewMemoryStore()
+		case "postgres":
+			store = ratelimit.NewPostgresStore(queries)
+		default:
+			log.Fatalf("Unknown rate limit store %q", cfg.RateLimit.Store)
+		}
+		app.Post("/v1/chats", middleware.RateLimit(store, "chats",
+			ratelimit.PerMinute(cfg.RateLimit.ChatsPerMinute, cfg.RateLimit.ChatBurst)))
+		messageLimit := middleware.RateLimit(store, api.MessageRateLimitScope, messageRate)
+		app.Post("/v1/chats/:chatId/messages", messageLimit)
+		app.Post("/v1/chats/:chatId/messages/:messageId/regenerate", messageLimit)
+		app.Post("/v1/chats/:chatId/messages/:messageId/edit", messageLimit)
+		rateLimitStore = store
+	}
+
 	// Setup routes
-	chatServer := &api.ChatServer{Store: queries}
+	generations := services.NewGenerations()
+	quotas := services.NewQuotas(cfg.Quota, queries)
+	tokenizer, err := services.NewTokenizer(cfg.Context.Tokenizer)
+	if err != nil {
+		log.Fatalf("Failed to create tokenizer: %v", err)
+	}
+	contextWindow := services.NewContextWindow(cfg.Context, tokenizer, queries, llm)
+	prompts := services.NewPrompts(cfg.Prompt, queries)
+	if err := prompts.LoadFiles(context.Background()); err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+
+	// Answer from the documents of the knowledge base if enabled
+	var knowledgeBase *knowledge.Base
+	if cfg.Knowledge.Enabled {
+		embedder, err := knowledge.NewEmbedder(cfg.Knowledge)
+		if err != nil {
+			log.Fatalf("Failed to create embedding provider: %v", err)
+		}
+		vectors, err := knowledge.NewVectorStore(context.Background(), cfg.Knowledge.VectorStore, dbConn)
+		if err != nil {
+			log.Fatalf("Failed to create vector store: %v", err)
+		}
+		knowledgeBase = knowledge.NewBase(cfg.Knowledge, dbConn, queries, embedder, vectors)
+		knowledgeBase.Start(context.Background())
+	}
+
+	// Let the LLM look up the devices of the user and call the tools of MCP servers if enabled
+	var tools *services.ToolRegistry
+	if cfg.Tools.Enabled || cfg.MCP.Enabled {
+		tools = services.NewToolRegistry(cfg.Tools)
+	}
+	if cfg.Tools.Enabled {
+		directory, err := devices.NewDirectory(cfg.Tools)
+		if err != nil {
+			log.Fatalf("Failed to create device directory: %v", err)
+		}
+		for _, tool := range services.DeviceTools(directory) {
+			if err := tools.Register(tool); err != nil {
+				log.Fatalf("Failed to register tool: %v", err)
+			}
+		}
+	}
+	var mcpClients []*mcp.Client
+	if cfg.MCP.Enabled {
+		mcpConfig, err := mcp.LoadConfig(cfg.MCP.ConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load MCP configuration: %v", err)
+		}
+		connectCtx, cancel := context.WithTimeout(context.Background(), cfg.MCP.ConnectTimeout)
+		mcpClients = mcp.ConnectAll(connectCtx, mcpConfig)
+		cancel()
+		for _, client := range mcpClients {
+			for _, tool := range services.MCPTools(client, mcpConfig.Allowed) {
+				// Tools of external servers may have names or schemas the LLM APIs don't accept
+				if err := tools.Register(tool); err != nil {
+					log.Printf("Skipping MCP tool: %v", err)
+				}
+			}
+		}
+	}
+	// Push chat changes to the event streams of connected clients
+	notifier := services.NewNotifier()
+	var titles *services.Titles
+	if cfg.Chats.GenerateTitles {
+		titles = services.NewTitles(cfg.Chats, queries, llm)
+	}
+	chatServer := &api.ChatServer{
+		DB:               dbConn,
+		Store:            queries,
+		LLM:              llm,
+		Generations:      generations,
+		Events:           events,
+		Quotas:           quotas,
+		Context:          contextWindow,
+		Prompts:          prompts,
+		Knowledge:        knowledgeBase,
+		Tools:            tools,
+		Notifier:         notifier,
+		Importer:         importer.NewImporter(dbConn, queries),
+		DataSubjects:     services.NewDataSubjects(cfg.Privacy, dbConn, queries),
+		Titles:           titles,
+		MaxContentLength: cfg.Validation.MaxContentLength,
+		RateLimits:       rateLimitStore,
+		MessageRate:      messageRate,
+	}
+	// Let agents use the chats of the user over MCP if enabled
+	if cfg.MCP.ServerEnabled {
+		chatServer.MCP = mcp.NewServer("ai-chat-service-go", "1.0.0")
+	}
 	api.RegisterHandlers(app, chatServer)
 
+	// Purge deleted and inactive chats once their retention period is over
+	var chatPurger *services.ChatPurger
+	if services.PurgeEnabled(cfg.Chats) {
+		chatPurger = services.NewChatPurger(cfg.Chats, dbConn, queries)
+		chatPurger.Start(context.Background())
+	}
+
 	// Start server
-	log.Printf("Starting server on port %s", cfg.Server.Port)
-	if err := app.Listen(":" + cfg.Server.Port); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on port %s", cfg.Server.Port)
+		listenErr <- app.Listen(":" + cfg.Server.Port)
+	}()
+
+	select {
+	case err := <-listenErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	shutdown(app, checker, generations, notifier, knowledgeBase, chatPurger, mcpClients, events, dbConn, cfg.Server)
+}
+
+// shutdown drains the instance: it reports not ready, stops accepting requests,
+// waits for in-flight generations up to the deadline and closes all resources
+func shutdown(app *fiber.App, checker *health.Checker, generations *services.Generations, notifier *services.Notifier, knowledgeBase *knowledge.Base, chatPurger *services.ChatPurger, mcpClients []*mcp.Client, events *opensearch.Sink, dbConn *sql.DB, cfg config.ServerConfig) {
+	log.Printf("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+
+	// Give load balancers time to notice the instance is no longer ready
+	checker.SetDraining(true)
+	time.Sleep(cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Event streams never finish on their own, end them so the server can shut down
+	notifier.Close()
+
+	drained := make(chan error, 1)
+	go func() { drained <- generations.Drain(ctx) }()
+
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	if err := <-drained; err != nil {
+		log.Printf("Shutdown deadline exceeded, in-flight replies were persisted as incomplete")
+	}
+
+	if knowledgeBase != nil {
+		if err := knowledgeBase.Close(ctx); err != nil {
+			log.Printf("Shutdown deadline exceeded, interrupted ingestion jobs are failed on the next start")
+		}
+	}
+
+	if chatPurger != nil {
+		if err := chatPurger.Close(ctx); err != nil {
+			log.Printf("Shutdown deadline exceeded while purging deleted chats")
+		}
+	}
+
+	for _, client := range mcpClients {
+		if err := client.Close(); err != nil {
+			log.Printf("Failed to close MCP server %s: %v", client.Name(), err)
+		}
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := events.Close(flushCtx); err != nil {
+		log.Printf("Failed to flush OpenSearch events: %v", err)
+	}
+
+	if err := dbConn.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
 	}
+	log.Printf("Shutdown complete")
 }
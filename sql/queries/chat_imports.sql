@@ -0,0 +1,9 @@
+-- name: GetImportedChatID :one
+SELECT chat_id FROM chat_imports
+WHERE user_email = $1 AND source = $2 AND external_id = $3;
+
+-- name: CreateChatImport :execrows
+-- A chat imported concurrently by another import is not recorded again, the caller rolls its chat back
+INSERT INTO chat_imports (user_email, source, external_id, chat_id, imported_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_email, source, external_id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- name: GetChatSummary :one
+SELECT * FROM chat_summaries
+WHERE chat_id = $1 LIMIT 1;
+
+-- name: UpsertChatSummary :one
+-- Replaces the summary of a chat, the rolling summary always covers the whole dropped prefix
+INSERT INTO chat_summaries (chat_id, content, last_message_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (chat_id) DO UPDATE
+SET content = EXCLUDED.content, last_message_id = EXCLUDED.last_message_id, updated_at = EXCLUDED.updated_at
+RETURNING *;
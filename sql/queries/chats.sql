@@ -1,18 +1,111 @@
 -- name: GetChat :one
+-- Deleted chats are gone for the user, they only wait for the purge
 SELECT * FROM chats
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetChatsByUserEmail :many
-SELECT * FROM chats
-WHERE user_email = $1
-ORDER BY last_active_date DESC;
+-- A page of the user's chats, pinned chats first and then newest first by the sort date (last_active_date or created_at).
+-- The cursor is the position of the last chat of the previous page, filters that are not set match all chats.
+-- The message count and last message only cover the active conversation, not discarded variants and branches.
+SELECT c.*,
+    conversation.message_count,
+    conversation.last_message
+FROM chats c
+LEFT JOIN LATERAL (
+    -- The active conversation follows the active continuation of every message, the latest one if none is active,
+    -- like the message tree of the API. Tool call records hang off replies and are not continuations.
+    WITH RECURSIVE active_path AS (
+        (SELECT m.id, m.sender_type, m.content, m.created_at FROM messages m
+        WHERE m.chat_id = c.id AND m.parent_id IS NULL
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1)
+        UNION ALL
+        SELECT next.id, next.sender_type, next.content, next.created_at FROM active_path p
+        CROSS JOIN LATERAL (
+            SELECT m.id, m.sender_type, m.content, m.created_at FROM messages m
+            WHERE m.parent_id = p.id AND m.sender_type <> 'backend'
+            ORDER BY m.active DESC, m.created_at DESC
+            LIMIT 1
+        ) next
+    )
+    SELECT
+        COUNT(*) FILTER (WHERE sender_type <> 'backend') AS message_count,
+        (SELECT left(content, 200) FROM active_path
+            WHERE sender_type <> 'backend'
+            ORDER BY created_at DESC
+            LIMIT 1) AS last_message
+    FROM active_path
+) conversation ON TRUE
+WHERE c.user_email = sqlc.arg(user_email) AND c.deleted_at IS NULL
+    AND (sqlc.narg(archived)::boolean IS NULL OR c.archived = sqlc.narg(archived))
+    AND (sqlc.narg(pinned)::boolean IS NULL OR c.pinned = sqlc.narg(pinned))
+    AND (sqlc.narg(title)::text IS NULL OR strpos(lower(c.title), lower(sqlc.narg(title))) > 0)
+    AND (sqlc.narg(date_from)::timestamptz IS NULL OR CASE WHEN sqlc.arg(sort_by)::text = 'created_at' THEN c.created_at ELSE c.last_active_date END >= sqlc.narg(date_from))
+    AND (sqlc.narg(date_to)::timestamptz IS NULL OR CASE WHEN sqlc.arg(sort_by)::text = 'created_at' THEN c.created_at ELSE c.last_active_date END < sqlc.narg(date_to))
+    AND (sqlc.narg(cursor_id)::uuid IS NULL
+        OR (c.pinned, CASE WHEN sqlc.arg(sort_by)::text = 'created_at' THEN c.created_at ELSE c.last_active_date END, c.id) < (sqlc.narg(cursor_pinned)::boolean, sqlc.narg(cursor_date)::timestamptz, sqlc.narg(cursor_id)))
+ORDER BY c.pinned DESC, CASE WHEN sqlc.arg(sort_by)::text = 'created_at' THEN c.created_at ELSE c.last_active_date END DESC, c.id DESC
+LIMIT sqlc.arg(page_limit);
 
 -- name: CreateChat :one
-INSERT INTO chats (id, title, user_email, last_active_date, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO chats (id, title, user_email, last_active_date, created_at, updated_at, tenant)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: UpdateChatLastActive :exec
 UPDATE chats
 SET last_active_date = $2, updated_at = $3
 WHERE id = $1;
+
+-- name: UpdateChat :one
+-- Fields that are not set keep their value
+UPDATE chats
+SET title = COALESCE(sqlc.narg(title), title),
+    pinned = COALESCE(sqlc.narg(pinned), pinned),
+    archived = COALESCE(sqlc.narg(archived), archived),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteChat :exec
+UPDATE chats
+SET deleted_at = $2, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: LockChat :exec
+-- Serializes adding messages to the chat until the transaction ends, so siblings get distinct variant numbers. Inserts
+-- that only reference the chat are not blocked.
+SELECT id FROM chats
+WHERE id = $1
+FOR NO KEY UPDATE;
+
+-- name: PurgeDeletedChats :one
+-- Removes a batch of chats deleted before the cutoff, their messages and summaries are removed with them.
+-- Returns the number of chats and messages removed.
+WITH batch AS (
+    SELECT id FROM chats
+    WHERE deleted_at < sqlc.arg(deleted_before)
+    ORDER BY deleted_at
+    LIMIT sqlc.arg(batch_size)
+), purged AS (
+    DELETE FROM chats
+    WHERE id IN (SELECT id FROM batch)
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM purged) AS chats,
+    (SELECT COUNT(*) FROM messages WHERE chat_id IN (SELECT id FROM batch)) AS messages;
+
+-- name: CountDeletedChats :one
+-- The chats and messages PurgeDeletedChats would remove, for dry runs
+SELECT COUNT(DISTINCT c.id) AS chats, COUNT(m.id) AS messages
+FROM chats c
+LEFT JOIN messages m ON m.chat_id = c.id
+WHERE c.deleted_at < sqlc.arg(deleted_before);
+
+-- name: UpdateChatTitle :one
+-- Only replaces the title the new one was generated for, a rename by the user in the meantime is kept
+UPDATE chats
+SET title = sqlc.arg(title), updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND title = sqlc.arg(previous_title) AND deleted_at IS NULL
+RETURNING *;
@@ -0,0 +1,41 @@
+-- name: ListChatsByUserEmail :many
+-- All chats of the user including archived and deleted ones
+SELECT * FROM chats
+WHERE user_email = $1
+ORDER BY created_at ASC;
+
+-- name: ListTokenUsageByUserEmail :many
+SELECT * FROM token_usage
+WHERE user_email = $1
+ORDER BY created_at ASC;
+
+-- name: ListChatImportsByUserEmail :many
+SELECT * FROM chat_imports
+WHERE user_email = $1
+ORDER BY imported_at ASC;
+
+-- name: CountMessagesByUserEmail :one
+SELECT COUNT(*) FROM messages m
+JOIN chats c ON c.id = m.chat_id
+WHERE c.user_email = $1;
+
+-- name: DeleteChatsByUserEmail :execrows
+-- Messages, their sources, chat summaries and import records are deleted with the chats
+DELETE FROM chats
+WHERE user_email = $1;
+
+-- name: AnonymizeTokenUsage :execrows
+-- The usage stays counted for the tenant quota, but no longer belongs to a user
+UPDATE token_usage
+SET user_email = ''
+WHERE user_email = $1;
+
+-- name: DeleteRateLimitBucketsByUser :execrows
+-- Buckets of signed-in users are keyed scope:user:tenant:email
+DELETE FROM rate_limit_buckets
+WHERE key LIKE '%:user:%' AND right(key, length(sqlc.arg(user_email)::text) + 1) = ':' || sqlc.arg(user_email)::text;
+
+-- name: CreateDataErasure :one
+INSERT INTO data_erasures (id, subject_hash, initiated_by, chats, messages, token_usage, erased_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
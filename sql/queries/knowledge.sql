@@ -0,0 +1,82 @@
+-- name: GetKnowledgeDocumentBySource :one
+SELECT * FROM kb_documents
+WHERE source = $1 LIMIT 1;
+
+-- name: GetKnowledgeDocument :one
+SELECT * FROM kb_documents
+WHERE id = $1 LIMIT 1;
+
+-- name: ListKnowledgeDocuments :many
+-- Documents with their number of chunks and the status of their latest ingestion
+SELECT d.id, d.source, d.title, d.format, d.created_at, d.updated_at,
+    (SELECT COUNT(*) FROM kb_chunks c WHERE c.document_id = d.id) AS chunks,
+    COALESCE((
+        SELECT j.status FROM kb_ingestion_jobs j
+        WHERE j.document_id = d.id
+        ORDER BY j.created_at DESC LIMIT 1
+    ), 'done')::text AS status
+FROM kb_documents d
+ORDER BY d.title ASC;
+
+-- name: DeleteKnowledgeDocument :execrows
+DELETE FROM kb_documents
+WHERE id = $1;
+
+-- name: UpsertKnowledgeDocument :one
+INSERT INTO kb_documents (id, source, title, format, content, content_hash, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (source) DO UPDATE
+SET title = EXCLUDED.title, format = EXCLUDED.format, content = EXCLUDED.content,
+    content_hash = EXCLUDED.content_hash, updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: DeleteKnowledgeChunksByDocumentID :exec
+DELETE FROM kb_chunks
+WHERE document_id = $1;
+
+-- name: CountKnowledgeChunks :one
+SELECT COUNT(*) FROM kb_chunks
+WHERE document_id = $1;
+
+-- name: CreateKnowledgeChunk :one
+INSERT INTO kb_chunks (id, document_id, position, content, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListKnowledgeChunks :many
+-- All chunks with the title of their document, used to rebuild the in-memory vector store
+SELECT c.id, c.document_id, c.position, c.content, d.title
+FROM kb_chunks c
+JOIN kb_documents d ON d.id = c.document_id
+ORDER BY c.document_id, c.position;
+
+-- name: CreateKnowledgeJob :one
+INSERT INTO kb_ingestion_jobs (id, document_id, source, kind, status, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: UpdateKnowledgeJob :one
+UPDATE kb_ingestion_jobs
+SET document_id = $2, status = $3, error = $4, chunks = $5, embedded_chunks = $6, updated_at = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: GetKnowledgeJob :one
+SELECT * FROM kb_ingestion_jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLatestKnowledgeJobByDocumentID :one
+SELECT * FROM kb_ingestion_jobs
+WHERE document_id = $1
+ORDER BY created_at DESC LIMIT 1;
+
+-- name: ListKnowledgeJobs :many
+SELECT * FROM kb_ingestion_jobs
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: FailStaleKnowledgeJobs :execrows
+-- Jobs are queued in memory, jobs that stopped making progress were lost in a restart
+UPDATE kb_ingestion_jobs
+SET status = 'failed', error = sqlc.arg(error), updated_at = sqlc.arg(updated_at)
+WHERE status NOT IN ('done', 'failed') AND updated_at < sqlc.arg(stale_before);
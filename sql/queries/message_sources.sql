@@ -0,0 +1,8 @@
+-- name: CreateMessageSource :exec
+INSERT INTO message_sources (message_id, position, document_id, title, excerpt, score)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetMessageSources :many
+SELECT * FROM message_sources
+WHERE message_id = ANY(sqlc.arg(message_ids)::uuid[])
+ORDER BY message_id, position;
@@ -2,12 +2,65 @@
 SELECT * FROM messages
 WHERE id = $1 LIMIT 1;
 
+-- name: GetActivePathAfter :many
+-- The next messages of the active conversation after the message, from its start if after_id is NULL. Follows the
+-- active continuation of every message, the latest one if none is active, like the message tree of the API. Tool call
+-- records are not continuations and are left out. Pages through long conversations with the last message as cursor.
+WITH RECURSIVE active_path AS (
+    (SELECT m.*, 1 AS depth FROM messages m
+    WHERE m.chat_id = sqlc.arg(chat_id) AND m.parent_id IS NOT DISTINCT FROM sqlc.narg(after_id)
+        AND (m.parent_id IS NULL OR m.sender_type <> 'backend')
+    ORDER BY m.active DESC, m.created_at DESC
+    LIMIT 1)
+    UNION ALL
+    SELECT next.*, p.depth + 1 FROM active_path p
+    CROSS JOIN LATERAL (
+        SELECT m.* FROM messages m
+        WHERE m.parent_id = p.id AND m.sender_type <> 'backend'
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1
+    ) next
+    WHERE p.depth < sqlc.arg(page_size)
+)
+SELECT id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, content_tsv, parent_id, variant, active
+FROM active_path
+ORDER BY depth;
+
 -- name: GetMessagesByChatID :many
+-- All messages of the chat including inactive branches, the conversation is the active path through them
 SELECT * FROM messages
 WHERE chat_id = $1
 ORDER BY created_at ASC;
 
 -- name: CreateMessage :one
-INSERT INTO messages (id, content, sender_type, chat_id, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING *;
\ No newline at end of file
+-- The message is numbered as next variant among its siblings of the same sender type. Concurrent messages with the same
+-- parent only get distinct numbers if the chat is locked with LockChat in the same transaction.
+INSERT INTO messages (id, content, sender_type, chat_id, created_at, updated_at, incomplete, prompt_template_id, parent_id, variant)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+    (SELECT COUNT(*) + 1 FROM messages
+     WHERE chat_id = $4 AND parent_id IS NOT DISTINCT FROM $9 AND sender_type = $3))
+RETURNING *;
+
+-- name: DeactivateSiblings :exec
+-- Takes the siblings out of the conversation when a message becomes active, tool call records stay attached
+UPDATE messages
+SET active = FALSE, updated_at = sqlc.arg(updated_at)
+WHERE chat_id = sqlc.arg(chat_id) AND parent_id IS NOT DISTINCT FROM sqlc.arg(parent_id)
+    AND id <> sqlc.arg(id) AND sender_type <> 'backend' AND active;
+
+-- name: ActivateMessage :exec
+UPDATE messages
+SET active = TRUE, updated_at = $2
+WHERE id = $1 AND NOT active;
+
+-- name: AttachToolCalls :exec
+-- Moves the records of the tool calls made while generating a reply below the reply
+UPDATE messages
+SET parent_id = sqlc.arg(reply_id)
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: CountVariantsByChatID :many
+-- Number of siblings per parent and sender type in the chat
+SELECT parent_id, sender_type, COUNT(*) AS variants FROM messages
+WHERE chat_id = $1
+GROUP BY parent_id, sender_type;
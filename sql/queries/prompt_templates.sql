@@ -0,0 +1,21 @@
+-- name: GetPromptTemplate :one
+SELECT * FROM prompt_templates
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLatestPromptTemplate :one
+SELECT * FROM prompt_templates
+WHERE name = $1
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: ListLatestPromptTemplates :many
+SELECT DISTINCT ON (name) * FROM prompt_templates
+ORDER BY name, version DESC;
+
+-- name: CreatePromptTemplateVersion :one
+-- Adds the next version of the template with the given name
+INSERT INTO prompt_templates (id, name, version, content, created_at)
+SELECT sqlc.arg(id), sqlc.arg(name)::text, COALESCE(MAX(version), 0) + 1, sqlc.arg(content), sqlc.arg(created_at)
+FROM prompt_templates
+WHERE name = sqlc.arg(name)::text
+RETURNING *;
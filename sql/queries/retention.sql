@@ -0,0 +1,87 @@
+-- name: ListChatTenants :many
+SELECT DISTINCT tenant FROM chats;
+
+-- name: PurgeInactiveChats :one
+-- Removes a batch of chats of the tenant that were last active before the cutoff, their messages are removed with them.
+-- Returns the number of chats and messages removed.
+WITH batch AS (
+    SELECT id FROM chats
+    WHERE tenant = sqlc.arg(tenant) AND last_active_date < sqlc.arg(inactive_before)
+    ORDER BY last_active_date
+    LIMIT sqlc.arg(batch_size)
+), purged AS (
+    DELETE FROM chats
+    WHERE id IN (SELECT id FROM batch)
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM purged) AS chats,
+    (SELECT COUNT(*) FROM messages WHERE chat_id IN (SELECT id FROM batch)) AS messages;
+
+-- name: CountInactiveChats :one
+-- The chats and messages PurgeInactiveChats would remove, for dry runs
+SELECT COUNT(DISTINCT c.id) AS chats, COUNT(m.id) AS messages
+FROM chats c
+LEFT JOIN messages m ON m.chat_id = c.id
+WHERE c.tenant = sqlc.arg(tenant) AND c.last_active_date < sqlc.arg(inactive_before);
+
+-- name: CountExpiredMessages :one
+-- The messages the message retention would remove with their tool call records, for dry runs
+SELECT COUNT(*) AS messages
+FROM messages m
+JOIN chats c ON c.id = m.chat_id
+LEFT JOIN messages p ON p.id = m.parent_id
+WHERE c.tenant = sqlc.arg(tenant) AND (m.created_at < sqlc.arg(created_before)
+    OR (m.sender_type = 'backend' AND p.created_at < sqlc.arg(created_before)));
+
+-- name: ListExpiredMessages :many
+-- A batch of messages of the chats of the tenant that were created before the cutoff, oldest first. They are locked, so
+-- no reply can be added to them while the batch is removed.
+SELECT m.id FROM messages m
+JOIN chats c ON c.id = m.chat_id
+WHERE c.tenant = sqlc.arg(tenant) AND m.created_at < sqlc.arg(created_before)
+ORDER BY m.created_at
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE OF m;
+
+-- name: DetachContinuations :exec
+-- Newer messages continuing the messages about to be removed start what is left of the conversation. They stay active
+-- if the message they continued was active, so the active conversation goes on where it was. Tool call records stay
+-- attached and are removed with their message.
+UPDATE messages m
+SET parent_id = NULL, active = m.active AND p.active
+FROM messages p
+WHERE p.id = m.parent_id AND p.id = ANY(sqlc.arg(ids)::uuid[])
+    AND NOT m.id = ANY(sqlc.arg(ids)::uuid[]) AND m.sender_type <> 'backend';
+
+-- name: DeleteMessagesWithAttachments :execrows
+-- Removes the messages with the tool call records attached to them, their continuations have to be detached before
+DELETE FROM messages
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) OR parent_id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: TryAdvisoryLock :one
+-- Session-level lock, it is held until it is released on the same connection or the connection is closed
+SELECT pg_try_advisory_lock(sqlc.arg(key)) AS acquired;
+
+-- name: ReleaseAdvisoryLock :exec
+SELECT pg_advisory_unlock(sqlc.arg(key));
+
+-- name: CreatePurgeRun :exec
+INSERT INTO purge_runs (id, dry_run, deleted_chats, inactive_chats, messages, expired_messages, error, started_at, finished_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListPurgeRuns :many
+SELECT * FROM purge_runs
+ORDER BY started_at DESC
+LIMIT $1;
+
+-- name: GetPurgeTotals :one
+-- Rows removed by all purge runs, dry runs removed nothing and are left out
+SELECT
+    COUNT(*) AS runs,
+    COALESCE(SUM(deleted_chats), 0)::bigint AS deleted_chats,
+    COALESCE(SUM(inactive_chats), 0)::bigint AS inactive_chats,
+    COALESCE(SUM(messages), 0)::bigint AS messages,
+    COALESCE(SUM(expired_messages), 0)::bigint AS expired_messages
+FROM purge_runs
+WHERE NOT dry_run;
@@ -0,0 +1,46 @@
+-- name: SearchChats :many
+-- Chat titles and messages of the user's chats matching the query, best matches first. Deleted chats are not searched.
+-- Only messages of the active conversation are searched, it follows the active continuation of every message, the latest
+-- one if none is active, like the message tree of the API. Backend messages like the system prompt, replaced variants and
+-- the branches left behind by them are not shown to users and therefore not searched. Snippets are only generated for the
+-- requested page.
+WITH RECURSIVE query AS (
+    SELECT websearch_to_tsquery('simple', sqlc.arg(query)) AS q
+), matches AS (
+    SELECT m.id, m.chat_id, c.title, m.sender_type, m.content, ts_rank(m.content_tsv, query.q) AS rank, m.created_at
+    FROM messages m
+    JOIN chats c ON c.id = m.chat_id, query
+    WHERE c.user_email = sqlc.arg(user_email) AND c.deleted_at IS NULL AND m.sender_type <> 'backend' AND m.content_tsv @@ query.q
+), active_path AS (
+    SELECT root.id FROM (SELECT DISTINCT chat_id FROM matches) matched
+    CROSS JOIN LATERAL (
+        SELECT m.id FROM messages m
+        WHERE m.chat_id = matched.chat_id AND m.parent_id IS NULL
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1
+    ) root
+    UNION ALL
+    SELECT next.id FROM active_path p
+    CROSS JOIN LATERAL (
+        SELECT m.id FROM messages m
+        WHERE m.parent_id = p.id AND m.sender_type <> 'backend'
+        ORDER BY m.active DESC, m.created_at DESC
+        LIMIT 1
+    ) next
+), hits AS (
+    SELECT c.id AS chat_id, NULL::uuid AS message_id, c.title AS chat_title, NULL::text AS sender_type,
+        c.title AS content, ts_rank(c.title_tsv, query.q) AS rank, c.created_at
+    FROM chats c, query
+    WHERE c.user_email = sqlc.arg(user_email) AND c.deleted_at IS NULL AND c.title_tsv @@ query.q
+    UNION ALL
+    SELECT chat_id, id, title, sender_type, content, rank, created_at
+    FROM matches
+    WHERE id IN (SELECT id FROM active_path)
+    ORDER BY rank DESC, created_at DESC
+    LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset)
+)
+SELECT hits.chat_id, hits.message_id, hits.chat_title, hits.sender_type,
+    ts_headline('simple', hits.content, query.q, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2')::text AS snippet,
+    hits.rank, hits.created_at
+FROM hits, query
+ORDER BY hits.rank DESC, hits.created_at DESC;
@@ -0,0 +1,48 @@
+-- name: CreateTokenUsage :one
+INSERT INTO token_usage (id, message_id, chat_id, user_email, tenant, model, prompt_tokens, completion_tokens, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetUserTenant :one
+-- The tenant the user last consumed tokens for, empty for users without usage. Imported chats get it like migration 016
+-- assigned it to the chats that existed before chats had a tenant.
+SELECT COALESCE((
+    SELECT tenant FROM token_usage
+    WHERE user_email = $1
+    ORDER BY created_at DESC
+    LIMIT 1
+), '')::text AS tenant;
+
+-- name: GetUserTokensSince :one
+SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0)::bigint AS total
+FROM token_usage
+WHERE user_email = $1 AND created_at >= $2;
+
+-- name: GetTenantTokensSince :one
+SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0)::bigint AS total
+FROM token_usage
+WHERE tenant = $1 AND created_at >= $2;
+
+-- name: GetDailyTokenUsage :many
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+       SUM(prompt_tokens)::bigint AS prompt_tokens,
+       SUM(completion_tokens)::bigint AS completion_tokens
+FROM token_usage
+WHERE user_email = sqlc.arg(user_email) AND created_at >= sqlc.arg(from_time) AND created_at < sqlc.arg(to_time)
+GROUP BY day
+ORDER BY day ASC;
+
+-- name: GetChatTokenUsage :many
+SELECT u.chat_id, c.title,
+       SUM(u.prompt_tokens)::bigint AS prompt_tokens,
+       SUM(u.completion_tokens)::bigint AS completion_tokens
+FROM token_usage u
+JOIN chats c ON c.id = u.chat_id
+WHERE u.user_email = sqlc.arg(user_email) AND u.created_at >= sqlc.arg(from_time) AND u.created_at < sqlc.arg(to_time)
+GROUP BY u.chat_id, c.title
+ORDER BY SUM(u.prompt_tokens + u.completion_tokens) DESC;
+
+-- name: GetMessageTokenUsage :many
+-- Model and tokens of the given replies
+SELECT message_id, model, prompt_tokens, completion_tokens FROM token_usage
+WHERE message_id = ANY(sqlc.arg(message_ids)::uuid[]);
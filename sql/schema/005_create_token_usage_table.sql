@@ -0,0 +1,25 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Token usage per LLM message. Rows survive the deletion of chats and messages so quotas can't be reset by deleting chats.
+CREATE TABLE IF NOT EXISTS token_usage (
+    id UUID PRIMARY KEY,
+    message_id UUID,
+    chat_id UUID,
+    user_email TEXT NOT NULL,
+    tenant TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL,
+    completion_tokens INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_token_usage_message FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE SET NULL,
+    CONSTRAINT fk_token_usage_chat FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_usage_user_email_created_at ON token_usage(user_email, created_at);
+CREATE INDEX IF NOT EXISTS idx_token_usage_tenant_created_at ON token_usage(tenant, created_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_token_usage_tenant_created_at;
+DROP INDEX IF EXISTS idx_token_usage_user_email_created_at;
+DROP TABLE IF EXISTS token_usage;
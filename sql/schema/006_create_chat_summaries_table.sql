@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Rolling summary of the messages of a chat that no longer fit into the context window.
+-- The summary covers all messages up to and including last_message_id.
+CREATE TABLE IF NOT EXISTS chat_summaries (
+    chat_id UUID PRIMARY KEY,
+    content TEXT NOT NULL,
+    last_message_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_chat_summaries_chat FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE,
+    CONSTRAINT fk_chat_summaries_last_message FOREIGN KEY (last_message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS chat_summaries;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- System prompt templates. Versions are immutable, changing a template adds a new version.
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT uq_prompt_templates_name_version UNIQUE (name, version)
+);
+
+-- The template version a system prompt was rendered from, and that was in effect for an LLM reply
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS prompt_template_id UUID;
+ALTER TABLE messages ADD CONSTRAINT fk_messages_prompt_template FOREIGN KEY (prompt_template_id) REFERENCES prompt_templates(id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_messages_prompt_template;
+ALTER TABLE messages DROP COLUMN IF EXISTS prompt_template_id;
+DROP TABLE IF EXISTS prompt_templates;
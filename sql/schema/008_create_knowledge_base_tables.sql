@@ -0,0 +1,65 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Documents of the knowledge base with their extracted text. Documents are identified by their source, e.g. the file name.
+CREATE TABLE IF NOT EXISTS kb_documents (
+    id UUID PRIMARY KEY,
+    source TEXT NOT NULL,
+    title TEXT NOT NULL,
+    format TEXT NOT NULL,
+    content TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT uq_kb_documents_source UNIQUE (source)
+);
+
+CREATE TABLE IF NOT EXISTS kb_chunks (
+    id UUID PRIMARY KEY,
+    document_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_kb_chunks_document FOREIGN KEY (document_id) REFERENCES kb_documents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_chunks_document_id ON kb_chunks(document_id);
+
+-- Embeddings are only stored in Postgres if the pgvector extension is available,
+-- otherwise the service keeps them in memory (KNOWLEDGE_VECTOR_STORE=memory).
+-- The dimensions depend on the embedding model, so the column is unconstrained and searched exactly.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector') THEN
+        CREATE EXTENSION IF NOT EXISTS vector;
+        CREATE TABLE IF NOT EXISTS kb_embeddings (
+            chunk_id UUID PRIMARY KEY,
+            model TEXT NOT NULL,
+            embedding vector NOT NULL,
+            CONSTRAINT fk_kb_embeddings_chunk FOREIGN KEY (chunk_id) REFERENCES kb_chunks(id) ON DELETE CASCADE
+        );
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- Knowledge base excerpts an LLM reply was based on
+CREATE TABLE IF NOT EXISTS message_sources (
+    message_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    document_id UUID,
+    title TEXT NOT NULL,
+    excerpt TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (message_id, position),
+    CONSTRAINT fk_message_sources_message FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message_sources_document FOREIGN KEY (document_id) REFERENCES kb_documents(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS message_sources;
+DROP TABLE IF EXISTS kb_embeddings;
+DROP INDEX IF EXISTS idx_kb_chunks_document_id;
+DROP TABLE IF EXISTS kb_chunks;
+DROP TABLE IF EXISTS kb_documents;
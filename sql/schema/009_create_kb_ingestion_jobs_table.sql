@@ -0,0 +1,26 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Background ingestions of knowledge base documents, uploaded through the admin API or read from the knowledge directory.
+-- The document is only known once an upload has been stored, jobs of deleted documents are kept as history.
+CREATE TABLE IF NOT EXISTS kb_ingestion_jobs (
+    id UUID PRIMARY KEY,
+    document_id UUID,
+    source TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    chunks INTEGER NOT NULL DEFAULT 0,
+    embedded_chunks INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_kb_ingestion_jobs_document FOREIGN KEY (document_id) REFERENCES kb_documents(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_ingestion_jobs_document_id ON kb_ingestion_jobs(document_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_kb_ingestion_jobs_created_at ON kb_ingestion_jobs(created_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_kb_ingestion_jobs_created_at;
+DROP INDEX IF EXISTS idx_kb_ingestion_jobs_document_id;
+DROP TABLE IF EXISTS kb_ingestion_jobs;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Full-text search over chat titles and messages. Chats are written in several languages,
+-- so the simple configuration is used: words are lower-cased but not stemmed.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS title_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', title)) STORED;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_chats_title_tsv ON chats USING GIN (title_tsv);
+CREATE INDEX IF NOT EXISTS idx_messages_content_tsv ON messages USING GIN (content_tsv);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_messages_content_tsv;
+DROP INDEX IF EXISTS idx_chats_title_tsv;
+ALTER TABLE messages DROP COLUMN IF EXISTS content_tsv;
+ALTER TABLE chats DROP COLUMN IF EXISTS title_tsv;
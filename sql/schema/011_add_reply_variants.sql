@@ -0,0 +1,26 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Regenerated replies are kept as variants: replies to the same message share the parent_id,
+-- are numbered by variant and only the active variant is part of the conversation.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES messages(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS variant INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages(parent_id);
+
+-- Existing replies answer the latest user message before them
+UPDATE messages AS reply
+SET parent_id = (
+    SELECT question.id FROM messages AS question
+    WHERE question.chat_id = reply.chat_id AND question.sender_type = 'user' AND question.created_at < reply.created_at
+    ORDER BY question.created_at DESC
+    LIMIT 1
+)
+WHERE reply.sender_type = 'llm';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_messages_parent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS active;
+ALTER TABLE messages DROP COLUMN IF EXISTS variant;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Messages form a tree: every message points to the message it follows, edited questions and regenerated
+-- replies are siblings. Among siblings the active one continues the conversation.
+-- Tool call records hang off the reply they were made for.
+CREATE INDEX IF NOT EXISTS idx_messages_chat_id_parent_id ON messages(chat_id, parent_id);
+
+-- User messages follow the latest active reply before them, the first one follows the system prompt if there is one
+UPDATE messages AS question
+SET parent_id = (
+    SELECT previous.id FROM messages AS previous
+    WHERE previous.chat_id = question.chat_id AND previous.created_at < question.created_at AND previous.active
+        AND (previous.sender_type <> 'backend' OR NOT EXISTS (
+            SELECT 1 FROM messages AS earlier
+            WHERE earlier.chat_id = previous.chat_id AND earlier.created_at < previous.created_at
+        ))
+    ORDER BY previous.created_at DESC
+    LIMIT 1
+)
+WHERE question.sender_type = 'user' AND question.parent_id IS NULL;
+
+-- Tool call records belong to the reply generated after them, or to the question if the generation failed
+UPDATE messages AS record
+SET parent_id = COALESCE((
+    SELECT reply.id FROM messages AS reply
+    WHERE reply.chat_id = record.chat_id AND reply.sender_type = 'llm' AND reply.created_at > record.created_at
+    ORDER BY reply.created_at
+    LIMIT 1
+), (
+    SELECT question.id FROM messages AS question
+    WHERE question.chat_id = record.chat_id AND question.sender_type = 'user' AND question.created_at < record.created_at
+    ORDER BY question.created_at DESC
+    LIMIT 1
+))
+WHERE record.sender_type = 'backend' AND record.parent_id IS NULL AND EXISTS (
+    SELECT 1 FROM messages AS earlier
+    WHERE earlier.chat_id = record.chat_id AND earlier.created_at < record.created_at
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+UPDATE messages SET parent_id = NULL WHERE sender_type <> 'llm';
+DROP INDEX IF EXISTS idx_messages_chat_id_parent_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Users pin chats to the top of the list and archive chats they are done with. Deleted chats are only marked,
+-- they are purged with their messages once the retention period is over.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_chats_deleted_at ON chats(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_chats_deleted_at;
+ALTER TABLE chats DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE chats DROP COLUMN IF EXISTS archived;
+ALTER TABLE chats DROP COLUMN IF EXISTS pinned;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Chats imported from archives of other chat services. The id a chat had in its source makes
+-- importing the same archive twice skip the chats imported before.
+CREATE TABLE IF NOT EXISTS chat_imports (
+    user_email TEXT NOT NULL,
+    source TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    chat_id UUID NOT NULL,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_email, source, external_id),
+    CONSTRAINT fk_chat_imports_chat FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_imports_chat_id ON chat_imports(chat_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_chat_imports_chat_id;
+DROP TABLE IF EXISTS chat_imports;
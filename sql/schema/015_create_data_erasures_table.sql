@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Erasures of the data of a user. The record proves the erasure without keeping personal data:
+-- the user is only identified by an HMAC of the lower-cased email address keyed with a server-side secret,
+-- or not at all if the service has no secret.
+CREATE TABLE IF NOT EXISTS data_erasures (
+    id UUID PRIMARY KEY,
+    subject_hash TEXT NOT NULL,
+    initiated_by TEXT NOT NULL CHECK (initiated_by IN ('self', 'admin')),
+    chats INTEGER NOT NULL,
+    messages INTEGER NOT NULL,
+    token_usage INTEGER NOT NULL,
+    erased_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_erasures_subject_hash ON data_erasures(subject_hash);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_data_erasures_subject_hash;
+DROP TABLE IF EXISTS data_erasures;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Chats remember the tenant of their user so the retention period of the tenant applies to them. Existing chats take
+-- the tenant their user last consumed tokens for, chats of users without usage fall under the global retention.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '';
+
+UPDATE chats c
+SET tenant = u.tenant
+FROM (
+    SELECT DISTINCT ON (user_email) user_email, tenant
+    FROM token_usage
+    WHERE user_email <> ''
+    ORDER BY user_email, created_at DESC
+) u
+WHERE c.user_email = u.user_email;
+
+CREATE INDEX IF NOT EXISTS idx_chats_tenant_last_active_date ON chats(tenant, last_active_date);
+
+-- Every run of the purge job with the number of rows it removed, or would have removed in dry-run mode
+CREATE TABLE IF NOT EXISTS purge_runs (
+    id UUID PRIMARY KEY,
+    dry_run BOOLEAN NOT NULL,
+    deleted_chats INTEGER NOT NULL,
+    inactive_chats INTEGER NOT NULL,
+    messages INTEGER NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_purge_runs_started_at ON purge_runs(started_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_purge_runs_started_at;
+DROP TABLE IF EXISTS purge_runs;
+DROP INDEX IF EXISTS idx_chats_tenant_last_active_date;
+ALTER TABLE chats DROP COLUMN IF EXISTS tenant;
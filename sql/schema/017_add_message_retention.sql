@@ -0,0 +1,9 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Purge runs also count the messages removed from chats that are kept because the messages are older than the
+-- message retention period
+ALTER TABLE purge_runs ADD COLUMN IF NOT EXISTS expired_messages INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE purge_runs DROP COLUMN IF EXISTS expired_messages;